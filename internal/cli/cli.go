@@ -1,45 +1,175 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/devusSs/minyls/internal/clip"
 	"github.com/devusSs/minyls/internal/env"
 	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/paths"
 	"github.com/devusSs/minyls/internal/storage"
 )
 
-var e *env.Env
+var (
+	e  *env.Env
+	st *storage.Store
+)
 
 // TODO: add functions for storage
 func initialize() error {
-	err := log.Setup()
+	err := initializeWithoutStorage()
 	if err != nil {
-		return fmt.Errorf("failed to setup log: %w", err)
+		return err
 	}
 
-	log.Log().Debug().Str("func", "cli.initialize").Msg("setup log")
+	st, err = storage.Open(e.StorageBackend)
+	if err != nil {
+		return fmt.Errorf("failed to init storage: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.Initialize").Msg("setup storage")
 
-	err = clip.Init()
+	return nil
+}
+
+// initializeWithoutStorage is used by commands which need
+// to work even if the storage cannot be initialized.
+func initializeWithoutStorage() error {
+	err := initializeLog()
 	if err != nil {
-		return fmt.Errorf("failed to init clip: %w", err)
+		return err
 	}
 
-	log.Log().Debug().Str("func", "cli.initialize").Msg("setup clip")
+	return initializeEnv()
+}
 
-	e, err = env.Load()
+// initializeEnv loads the environment.
+func initializeEnv() error {
+	err := loadEnv()
 	if err != nil {
 		return fmt.Errorf("failed to load env: %w", err)
 	}
 
-	log.Log().Debug().Str("func", "cli.initialize").Any("env", e).Msg("loaded environment")
+	log.Log().Debug().Str("func", "cli.initialize").Object("env", e).Msg("loaded environment")
+
+	return nil
+}
+
+// loadEnv loads the env of the selected profile
+// and registers its secrets for redaction in the logs.
+func loadEnv() error {
+	var err error
 
-	err = storage.Init(e.MinioLinkExpiry)
+	e, err = env.Load(global.profile)
 	if err != nil {
-		return fmt.Errorf("failed to init storage: %w", err)
+		return err
 	}
 
-	log.Log().Debug().Str("func", "cli.Initialize").Msg("setup storage")
+	log.AddSecrets(e.Secrets()...)
 
 	return nil
 }
+
+// loadEnvPartial loads the env like loadEnv, but missing required values do
+// not fail, see env.LoadPartial. e is set unless the env could not be loaded
+// at all, the returned error contains all problems found while loading.
+func loadEnvPartial() error {
+	pe, err := env.LoadPartial(global.profile)
+	if pe == nil {
+		return err
+	}
+
+	e = pe
+	log.AddSecrets(e.Secrets()...)
+
+	log.Log().Debug().Str("func", "cli.loadEnvPartial").Object("env", e).Msg("loaded environment")
+
+	return err
+}
+
+// initializeLog migrates legacy files and sets up the log. It is used
+// by commands which need to work without a valid environment.
+func initializeLog() error {
+	// migrate before setting up the log so the old logs are moved as well
+	migrated, migrateErr := paths.MigrateLegacy()
+
+	lc, err := env.LoadLog(global.profile)
+	if err != nil {
+		return fmt.Errorf("failed to load log settings: %w", err)
+	}
+
+	err = log.Setup(logOptions(lc))
+	if err != nil {
+		return fmt.Errorf("failed to setup log: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.initialize").Msg("setup log")
+
+	if migrateErr != nil {
+		return fmt.Errorf("failed to migrate legacy files: %w", migrateErr)
+	}
+
+	if len(migrated) > 0 {
+		log.Log().Info().Str("func", "cli.initialize").Strs("files", migrated).Msg("migrated legacy files")
+	}
+
+	return nil
+}
+
+const megabyte = 1 << 20
+
+// logOptions converts the log settings, the global flags override them.
+func logOptions(lc *env.Log) log.Options {
+	level := lc.LogLevel
+	if global.verbose {
+		level = "debug"
+	}
+
+	if global.logLevel != "" {
+		level = global.logLevel
+	}
+
+	return log.Options{
+		Level:         level,
+		Format:        lc.LogFormat,
+		Destination:   lc.LogDestination,
+		SyslogAddress: lc.LogSyslogAddress,
+		MaxAge:        lc.LogMaxAge,
+		MaxFiles:      lc.LogMaxFiles,
+		MaxTotalSize:  lc.LogMaxTotalSizeMB * megabyte,
+		Compress:      lc.LogCompress,
+		Rolling:       lc.LogRolling,
+		MaxFileSize:   lc.LogMaxFileSizeMB * megabyte,
+	}
+}
+
+// newMinioClient creates a minio client from the environment
+// and sets up the buckets.
+func newMinioClient(ctx context.Context) (*minio.Client, error) {
+	mc, err := minio.NewClient(e.MinioEndpoint, e.MinioAccessKey, e.MinioAccessSecret)
+	if err != nil {
+		return nil, fmt.Errorf("could not create minio client: %w", err)
+	}
+
+	log.Log().
+		Debug().
+		Str("func", "cli.newMinioClient").
+		Str("endpoint", e.MinioEndpoint).
+		Str("access_key", e.MinioAccessKey).
+		Msg("created minio client")
+
+	err = mc.Setup(ctx, e.MinioBucketName, e.MinioRegion)
+	if err != nil {
+		return nil, fmt.Errorf("could not setup minio client: %w", err)
+	}
+
+	log.Log().
+		Debug().
+		Str("func", "cli.newMinioClient").
+		Str("bucket_name", e.MinioBucketName).
+		Str("region", e.MinioRegion).
+		Msg("setup minio client")
+
+	return mc, nil
+}
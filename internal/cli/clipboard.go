@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devusSs/minyls/internal/clip"
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// clipboardOff disables the clipboard, see MINYLS_CLIPBOARD and --no-clipboard.
+const clipboardOff = "off"
+
+func clipboardEnabled() bool {
+	return !global.noClipboard && e.Clipboard != clipboardOff
+}
+
+// copyToClipboard writes the formatted link of entry to the clipboard if it is enabled. Since
+// the link is printed as well, failures (e.g. on headless systems) are only warnings.
+func copyToClipboard(entry *storage.DataEntry) {
+	if !clipboardEnabled() {
+		log.Log().Debug().Str("func", "cli.copyToClipboard").Msg("clipboard disabled")
+		return
+	}
+
+	link, err := formatLink(entry)
+	if err != nil {
+		log.Log().Warn().Err(err).Str("func", "cli.copyToClipboard").Msg("could not format link")
+		fmt.Fprintln(os.Stderr, "warning: could not copy link to clipboard:", err)
+		return
+	}
+
+	b, err := clip.New(e.Clipboard, e.ClipboardCommand)
+	if err == nil {
+		err = b.Write(link)
+	}
+
+	if err != nil {
+		log.Log().Warn().Err(err).Str("func", "cli.copyToClipboard").Msg("could not write link to clip")
+		fmt.Fprintln(os.Stderr, "warning: could not copy link to clipboard:", err)
+		return
+	}
+
+	log.Log().Info().Str("func", "cli.copyToClipboard").Str("backend", b.Name()).Str("link", link).
+		Msg("wrote link to clip")
+}
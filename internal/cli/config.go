@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"github.com/devusSs/minyls/internal/env"
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/paths"
+	"github.com/devusSs/minyls/internal/yourls"
+)
+
+// Config manages the configuration.
+//
+// Available options:
+//   - init: prompts for all values, tests them and stores them as a profile
+//   - show: prints the effective config and the source of each value
+//   - validate: checks the effective config
+func Config() error {
+	err := initializeLog()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	if len(os.Args) != neededConfigArgsLen {
+		return fmt.Errorf("expected %d arguments, got %d", neededConfigArgsLen, len(os.Args))
+	}
+
+	switch os.Args[2] {
+	case "init":
+		return configInit()
+	case "show":
+		return configShow()
+	case "validate":
+		return configValidate()
+	default:
+		return fmt.Errorf("unknown config option '%s'", os.Args[2])
+	}
+}
+
+const neededConfigArgsLen = 3
+
+func configShow() error {
+	err := loadEnvPartial()
+	if e == nil {
+		return fmt.Errorf("failed to load env: %w", err)
+	}
+
+	// show the config anyway, it helps finding the problem
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.configShow").Msg("initialized")
+
+	configFile, err := paths.ConfigFile()
+	if err != nil {
+		return err
+	}
+
+	type configValue struct {
+		Key    string `json:"key"`
+		Value  string `json:"value"`
+		Source string `json:"source"`
+	}
+
+	shown := struct {
+		ConfigFile string        `json:"config_file"`
+		Profile    string        `json:"profile"`
+		Values     []configValue `json:"values"`
+	}{ConfigFile: configFile, Profile: e.Profile}
+
+	for _, f := range e.Fields() {
+		value := f.Value
+		if f.Secret && value != "" {
+			value = log.Redacted
+		}
+
+		shown.Values = append(shown.Values, configValue{Key: f.Key, Value: value, Source: f.Source})
+	}
+
+	return printResult(&result{
+		value: shown,
+		table: func(out io.Writer) error {
+			profile := shown.Profile
+			if profile == "" {
+				profile = "(none)"
+			}
+
+			fmt.Fprintln(out, "Config file:", shown.ConfigFile)
+			fmt.Fprintln(out, "Profile:", profile)
+			fmt.Fprintln(out)
+
+			w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "Key\tValue\tSource")
+
+			for _, v := range shown.Values {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, v.Value, v.Source)
+			}
+
+			return w.Flush()
+		},
+	}, outputTable)
+}
+
+func configValidate() error {
+	err := loadEnvPartial()
+	if e == nil {
+		return fmt.Errorf("failed to load env: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.configValidate").Msg("initialized")
+
+	err = errors.Join(err, validateEnv(e))
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	return printResult(messageResult("config is valid"), outputPlain)
+}
+
+func configInit() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	log.Log().Debug().Str("func", "cli.configInit").Msg("initialized")
+
+	var err error
+
+	profile := global.profile
+	if profile == "" {
+		profile, err = readLine("Profile name [default]: ")
+		if err != nil {
+			return fmt.Errorf("could not read profile name: %w", err)
+		}
+
+		if profile == "" {
+			profile = "default"
+		}
+	}
+
+	values, err := promptProfileValues()
+	if err != nil {
+		return err
+	}
+
+	err = checkProfile(ctx, values)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+
+		var ok bool
+		ok, err = confirm("Write the config anyway?")
+		if err != nil || !ok {
+			return errors.New("config not written")
+		}
+	}
+
+	configFile, err := paths.ConfigFile()
+	if err != nil {
+		return err
+	}
+
+	err = env.WriteProfile(configFile, profile, values)
+	if err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+
+	log.Log().Info().Str("func", "cli.configInit").Str("profile", profile).Msg("wrote config")
+
+	fmt.Printf("wrote profile '%s' to %s\n", profile, configFile)
+
+	return nil
+}
+
+// promptProfileValues prompts for every field of the env. Empty answers
+// keep the default, only values differing from it are returned.
+func promptProfileValues() (map[string]string, error) {
+	values := make(map[string]string)
+
+	for _, f := range (&env.Env{}).Fields() {
+		prompt := f.Name
+		if f.HasDefault && f.Default != "" {
+			prompt += " [" + f.Default + "]"
+		}
+
+		value, err := readInput(prompt+": ", f.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", f.Name, err)
+		}
+
+		if value == "" && !f.HasDefault {
+			return nil, fmt.Errorf("%s is required", f.Name)
+		}
+
+		if value == "" || value == f.Default {
+			continue
+		}
+
+		values[f.Name] = value
+	}
+
+	return values, nil
+}
+
+// checkProfile validates the profile values and tests
+// the connection to minio and YOURLS using them.
+func checkProfile(ctx context.Context, values map[string]string) error {
+	pe, err := env.ParseProfile(values)
+	if err != nil {
+		return err
+	}
+
+	err = validateEnv(pe)
+	if err != nil {
+		return err
+	}
+
+	mc, err := minio.NewClient(pe.MinioEndpoint, pe.MinioAccessKey, pe.MinioAccessSecret)
+	if err != nil {
+		return fmt.Errorf("could not create minio client: %w", err)
+	}
+
+	_, err = mc.BucketExists(ctx, pe.MinioBucketName+"-public")
+	if err != nil {
+		return fmt.Errorf("could not connect to minio: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "connected to minio")
+
+	if !pe.ShortenerEnabled() {
+		return nil
+	}
+
+	_, err = yourls.NewClient(pe.YOURLSEndpoint, pe.YOURLSSignature).Stats(ctx)
+	if err != nil {
+		return fmt.Errorf("could not connect to YOURLS: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "connected to YOURLS")
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minyls/internal/clip"
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/paths"
+	"github.com/devusSs/minyls/internal/yourls"
+)
+
+// Doctor checks the configuration, the connection to minio and YOURLS,
+// the clipboard and the local directories and prints a report.
+func Doctor() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := initializeLog()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.Doctor").Msg("initialized")
+
+	d := &doctor{}
+
+	d.check("history dir writable", func() error { return checkDirWritable(paths.DataDir) })
+	d.check("log dir writable", func() error { return checkDirWritable(paths.LogsDir) })
+
+	configValid := d.check("config", func() error {
+		err = loadEnvPartial()
+		if e == nil {
+			return err
+		}
+
+		return errors.Join(err, validateEnv(e))
+	})
+
+	if e != nil && !clipboardEnabled() {
+		d.skip("clipboard", "disabled")
+	} else {
+		d.check("clipboard", checkClipboard)
+	}
+
+	if !configValid {
+		d.skip("minio", "no valid config")
+		d.skip("YOURLS", "no valid config")
+		return d.result()
+	}
+
+	doctorMinio(ctx, d)
+	doctorYOURLS(ctx, d)
+
+	return d.result()
+}
+
+const doctorTimeout = 10 * time.Second
+
+func doctorMinio(ctx context.Context, d *doctor) {
+	u, err := url.Parse(e.MinioEndpoint)
+	if err != nil {
+		d.fail("minio endpoint", err)
+		return
+	}
+
+	if !d.check("minio DNS", func() error { return checkDNS(ctx, u.Hostname()) }) {
+		return
+	}
+
+	if u.Scheme == "https" && !d.check("minio TLS", func() error { return checkTLS(ctx, u) }) {
+		return
+	}
+
+	mc, err := minio.NewClient(e.MinioEndpoint, e.MinioAccessKey, e.MinioAccessSecret)
+	if err != nil {
+		d.fail("minio client", err)
+		return
+	}
+
+	for _, public := range []bool{true, false} {
+		bucket := e.MinioBucketName + "-private"
+		if public {
+			bucket = e.MinioBucketName + "-public"
+		}
+
+		if !d.check("bucket "+bucket, func() error { return checkBucket(ctx, mc, bucket, public) }) {
+			continue
+		}
+
+		d.check("probe object in "+bucket+" (put, presign, fetch, delete)", func() error {
+			ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+			defer cancel()
+
+			return mc.Probe(ctx, bucket)
+		})
+	}
+}
+
+func doctorYOURLS(ctx context.Context, d *doctor) {
+	if !e.ShortenerEnabled() {
+		d.skip("YOURLS", "no shortener configured")
+		return
+	}
+
+	d.check("YOURLS reachable and signature valid", func() error {
+		ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+		defer cancel()
+
+		_, err := yourls.NewClient(e.YOURLSEndpoint, e.YOURLSSignature).Stats(ctx)
+		return err
+	})
+}
+
+// checkClipboard checks the configured clipboard backend, with an
+// invalid config the backend is selected automatically.
+func checkClipboard() error {
+	name, command := clip.Auto, ""
+	if e != nil {
+		name, command = e.Clipboard, e.ClipboardCommand
+	}
+
+	b, err := clip.New(name, command)
+	if err != nil {
+		return err
+	}
+
+	err = b.Available()
+	if err != nil {
+		return fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	return nil
+}
+
+func checkDNS(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	_, err := net.DefaultResolver.LookupHost(ctx, host)
+	return err
+}
+
+func checkTLS(ctx context.Context, u *url.URL) error {
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	d := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// checkBucket checks the credentials, that the bucket exists
+// and that only the public bucket can be read by anyone.
+func checkBucket(ctx context.Context, mc *minio.Client, bucket string, public bool) error {
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	exists, err := mc.BucketExists(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return errors.New("bucket does not exist, it will be created on the next upload")
+	}
+
+	policy, err := mc.BucketPolicy(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	if public && !minio.AllowsPublicRead(policy) {
+		return errors.New("public bucket does not allow public reads")
+	}
+
+	if !public && minio.AllowsPublicRead(policy) {
+		return errors.New("private bucket allows public reads")
+	}
+
+	return nil
+}
+
+func checkDirWritable(dirFn func() (string, error)) error {
+	dir, err := dirFn()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, ".minyls-doctor-*")
+	if err != nil {
+		return err
+	}
+
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// doctor collects the results of the checks.
+type doctor struct {
+	results []doctorResult
+	failed  int
+}
+
+type doctorResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// check runs fn, records the result and reports whether it passed.
+func (d *doctor) check(name string, fn func() error) bool {
+	err := fn()
+	if err != nil {
+		d.fail(name, err)
+		return false
+	}
+
+	d.results = append(d.results, doctorResult{Name: name, Status: "pass"})
+	return true
+}
+
+func (d *doctor) fail(name string, err error) {
+	d.failed++
+
+	log.Log().Err(err).Str("func", "cli.doctor").Str("check", name).Msg("check failed")
+
+	d.results = append(d.results, doctorResult{Name: name, Status: "fail", Error: err.Error()})
+}
+
+func (d *doctor) skip(name string, reason string) {
+	d.results = append(d.results, doctorResult{Name: name, Status: "skip", Error: reason})
+}
+
+// result prints the report and returns an error if any check failed.
+func (d *doctor) result() error {
+	err := printResult(&result{
+		value: d.results,
+		plain: func(w io.Writer) error {
+			for _, r := range d.results {
+				line := fmt.Sprintf("[%s] %s", strings.ToUpper(r.Status), r.Name)
+				if r.Error != "" {
+					line += ": " + r.Error
+				}
+
+				fmt.Fprintln(w, line)
+			}
+
+			if d.failed == 0 {
+				fmt.Fprintln(w, "all checks passed")
+			}
+
+			return nil
+		},
+	}, outputPlain)
+	if err != nil {
+		return err
+	}
+
+	if d.failed > 0 {
+		return fmt.Errorf("%d check(s) failed", d.failed)
+	}
+
+	return nil
+}
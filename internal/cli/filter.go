@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// entryFilter filters and sorts entries, it is used by list and search.
+type entryFilter struct {
+	since          timeFlag
+	until          timeFlag
+	policy         string
+	name           string
+	expiringWithin durationFlag
+	tags           stringsFlag
+	sort           string
+	limit          int
+	// query is matched against the name, title, notes, tags and keyword of the entries.
+	query string
+}
+
+func (f *entryFilter) register(fs *flag.FlagSet) {
+	fs.Var(&f.since, "since", "only entries uploaded after this time (e.g. '3d' or '2006-01-02')")
+	fs.Var(&f.until, "until", "only entries uploaded before this time (e.g. '3d' or '2006-01-02')")
+	fs.StringVar(&f.policy, "policy", "", "only 'public' or 'private' entries")
+	fs.StringVar(&f.name, "name", "", "only entries whose original name matches the glob (e.g. '*.pdf')")
+	fs.Var(&f.expiringWithin, "expiring-within", "only entries whose link expires within the duration (e.g. '24h')")
+	fs.Var(&f.tags, "tag", "only entries with the tag, may be provided multiple times")
+	fs.StringVar(&f.sort, "sort", "", "sort by 'date' (newest first), 'size' (largest first) or 'expiry' (soonest first)")
+	fs.IntVar(&f.limit, "limit", 0, "show at most this many entries")
+}
+
+func (f *entryFilter) validate() error {
+	if f.policy != "" && f.policy != "public" && f.policy != "private" {
+		return fmt.Errorf("unexpected policy '%s' provided (expected 'public' or 'private')", f.policy)
+	}
+
+	if _, err := path.Match(f.name, ""); err != nil {
+		return fmt.Errorf("invalid name pattern '%s': %w", f.name, err)
+	}
+
+	if f.expiringWithin < 0 {
+		return fmt.Errorf("invalid expiring within '%s' provided", f.expiringWithin.String())
+	}
+
+	if !slices.Contains([]string{"", "date", "size", "expiry"}, f.sort) {
+		return fmt.Errorf("unexpected sort '%s' provided (expected 'date', 'size' or 'expiry')", f.sort)
+	}
+
+	if f.limit < 0 {
+		return fmt.Errorf("invalid limit %d provided", f.limit)
+	}
+
+	return nil
+}
+
+// apply returns the matching entries, sorted and limited.
+func (f *entryFilter) apply(entries []*storage.DataEntry) []*storage.DataEntry {
+	now := time.Now()
+
+	var matched []*storage.DataEntry
+	for _, entry := range entries {
+		if f.matches(entry, now) {
+			matched = append(matched, entry)
+		}
+	}
+
+	switch f.sort {
+	case "":
+		// the indexes return the entries in their own order
+		slices.SortStableFunc(matched, func(a, b *storage.DataEntry) int { return cmp.Compare(a.ID, b.ID) })
+	case "date":
+		slices.SortStableFunc(matched, func(a, b *storage.DataEntry) int { return b.Timestamp.Compare(a.Timestamp) })
+	case "size":
+		slices.SortStableFunc(matched, func(a, b *storage.DataEntry) int { return cmp.Compare(b.Size, a.Size) })
+	case "expiry":
+		slices.SortStableFunc(matched, compareExpiry)
+	}
+
+	if f.limit > 0 && len(matched) > f.limit {
+		matched = matched[:f.limit]
+	}
+
+	return matched
+}
+
+func (f *entryFilter) matches(entry *storage.DataEntry, now time.Time) bool {
+	switch {
+	case !f.since.IsZero() && entry.Timestamp.Before(f.since.Time),
+		!f.until.IsZero() && entry.Timestamp.After(f.until.Time),
+		f.policy != "" && entry.Policy() != f.policy:
+		return false
+	}
+
+	if f.name != "" {
+		ok, _ := path.Match(strings.ToLower(f.name), strings.ToLower(entry.OriginalName))
+		if !ok {
+			return false
+		}
+	}
+
+	if f.expiringWithin > 0 {
+		expiresAt := entry.ExpiresAt()
+		if entry.NeverExpires() || expiresAt.Before(now) || expiresAt.After(now.Add(time.Duration(f.expiringWithin))) {
+			return false
+		}
+	}
+
+	for _, tag := range f.tags {
+		if !entry.HasTag(tag) {
+			return false
+		}
+	}
+
+	return f.query == "" || matchesQuery(entry, f.query)
+}
+
+// matchesQuery reports whether all words of query are contained in the
+// original name, the title, the notes, the tags or the keyword of entry.
+func matchesQuery(entry *storage.DataEntry, query string) bool {
+	fields := append([]string{entry.OriginalName, entry.Title, entry.Notes, entry.Keyword()}, entry.Tags...)
+	text := strings.ToLower(strings.Join(fields, " "))
+
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareExpiry orders entries by their expiry, entries which never expire last.
+func compareExpiry(a, b *storage.DataEntry) int {
+	switch {
+	case a.NeverExpires() && b.NeverExpires():
+		return 0
+	case a.NeverExpires():
+		return 1
+	case b.NeverExpires():
+		return -1
+	default:
+		return a.ExpiresAt().Compare(b.ExpiresAt())
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseFlags parses args into fs and returns the remaining positional arguments.
+// Unlike fs.Parse it also allows flags after positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse flags: %w", err)
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id '%s' provided", s)
+	}
+
+	return id, nil
+}
+
+// stringsFlag collects the values of a flag provided multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	if value == "" {
+		return errors.New("value cannot be empty")
+	}
+
+	*f = append(*f, value)
+	return nil
+}
+
+// parseDuration parses a duration like time.ParseDuration,
+// but also allows whole days (e.g. '3d').
+func parseDuration(s string) (time.Duration, error) {
+	days, ok := strings.CutSuffix(s, "d")
+	if ok {
+		n, err := strconv.Atoi(days)
+		if err == nil {
+			return time.Duration(n) * day, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+
+	return d, nil
+}
+
+const day = 24 * time.Hour
+
+// durationFlag is a duration flag which also allows whole days.
+type durationFlag time.Duration
+
+func (f *durationFlag) String() string {
+	return time.Duration(*f).String()
+}
+
+func (f *durationFlag) Set(value string) error {
+	d, err := parseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*f = durationFlag(d)
+	return nil
+}
+
+// timeFlag is a point in time, either relative to now (e.g. '3d' for three
+// days ago) or absolute as date ('2006-01-02') or RFC 3339 timestamp.
+type timeFlag struct {
+	time.Time
+}
+
+func (f *timeFlag) String() string {
+	if f.IsZero() {
+		return ""
+	}
+
+	return f.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(value string) error {
+	d, err := parseDuration(value)
+	if err == nil {
+		f.Time = time.Now().Add(-d)
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			f.Time = t
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid time '%s' (expected a duration like '3d' or a date like '2006-01-02')", value)
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// linkFormats are the predefined link formats, any other
+// format (see --format and MINYLS_FORMAT) is a text/template.
+var linkFormats = map[string]string{
+	"link":     "{{.Link}}",
+	"markdown": "[{{.Name}}]({{.Link}})",
+	"image":    "![{{.Name}}]({{.Link}})",
+	"html":     `<a href="{{html .Link}}">{{html .Name}}</a>`,
+}
+
+// linkData is passed to the link format template.
+type linkData struct {
+	ID     int
+	Name   string
+	Size   int64
+	SHA256 string
+	Tags   []string
+	// Expires is the time the link expires at, the zero time if it never expires.
+	Expires time.Time
+	// Link is the short link, LongLink the presigned or gateway link.
+	Link     string
+	LongLink string
+}
+
+// defaultLinkFormat is used if neither --format nor MINYLS_FORMAT is set.
+const defaultLinkFormat = "link"
+
+// linkTemplate parses the predefined or custom format. Custom formats need
+// to contain an action, so typos of the predefined names are not printed
+// as is. It is executed once with empty data so that unknown fields are
+// reported before any upload.
+func linkTemplate(format string) (*template.Template, error) {
+	if f, ok := linkFormats[format]; ok {
+		format = f
+	} else if !strings.Contains(format, "{{") {
+		return nil, fmt.Errorf("unknown format '%s' (expected one of %s or a template like '{{.Link}}')",
+			format, strings.Join(slices.Sorted(maps.Keys(linkFormats)), ", "))
+	}
+
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+
+	err = tmpl.Execute(io.Discard, &linkData{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// linkFormat returns the format set via --format or MINYLS_FORMAT.
+func linkFormat() string {
+	format := global.format
+	if format == "" && e != nil {
+		format = e.Format
+	}
+
+	if format == "" {
+		return defaultLinkFormat
+	}
+
+	return format
+}
+
+// formatLink formats the link of entry using linkFormat.
+func formatLink(entry *storage.DataEntry) (string, error) {
+	tmpl, err := linkTemplate(linkFormat())
+	if err != nil {
+		return "", err
+	}
+
+	data := &linkData{
+		ID:       entry.ID,
+		Name:     entry.OriginalName,
+		Size:     entry.Size,
+		SHA256:   entry.SHA256,
+		Tags:     entry.Tags,
+		Expires:  entry.ExpiresAt(),
+		Link:     entry.YOURLSLink,
+		LongLink: entry.MinioLink,
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		return "", fmt.Errorf("could not format link: %w", err)
+	}
+
+	return b.String(), nil
+}
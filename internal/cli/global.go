@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// globalFlags are the flags available for all commands.
+// They need to be provided in front of the command.
+type globalFlags struct {
+	// profile is the name of the config profile to use.
+	profile string
+	// logLevel overrides the log level.
+	logLevel string
+	// verbose sets the log level to debug.
+	verbose bool
+	// output is the output format, see outputFormats.
+	output string
+	// noClipboard disables the clipboard.
+	noClipboard bool
+	// format overrides the link format, see MINYLS_FORMAT.
+	format string
+}
+
+var global globalFlags
+
+// ParseGlobalFlags parses the global flags in front of the command and
+// returns args without them. Flags after the command belong to the command.
+// The flags may be provided as '--name value', '--name=value' or with a single dash.
+func ParseGlobalFlags(args []string) ([]string, error) {
+	global = globalFlags{}
+
+	stringFlags := map[string]*string{
+		"profile":   &global.profile,
+		"log-level": &global.logLevel,
+		"output":    &global.output,
+		"format":    &global.format,
+	}
+
+	boolFlags := map[string]*bool{
+		"verbose":      &global.verbose,
+		"v":            &global.verbose,
+		"no-clipboard": &global.noClipboard,
+	}
+
+	i := 1
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+
+		if p, ok := stringFlags[name]; ok {
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("flag needs an argument: -%s", name)
+				}
+
+				i++
+				value = args[i]
+			}
+
+			*p = value
+			continue
+		}
+
+		p, ok := boolFlags[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown global flag: %s", arg)
+		}
+
+		*p = true
+		if hasValue {
+			var err error
+			*p, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid boolean value '%s' for flag -%s", value, name)
+			}
+		}
+	}
+
+	err := validateOutputFormat(global.output)
+	if err != nil {
+		return nil, err
+	}
+
+	if global.format != "" {
+		_, err = linkTemplate(global.format)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return append([]string{args[0]}, args[i:]...), nil
+}
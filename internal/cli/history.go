@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// History manages the history (data file) itself.
+//
+// Available options:
+//   - repair: restores a corrupted json data file from its newest valid backup,
+//     only the json storage backend keeps backups
+func History() error {
+	err := initializeWithoutStorage()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.History").Msg("initialized")
+
+	if len(os.Args) != neededHistoryArgsLen {
+		return fmt.Errorf("expected %d arguments, got %d", neededHistoryArgsLen, len(os.Args))
+	}
+
+	switch os.Args[2] {
+	case "repair":
+		if e.StorageBackend != storage.BackendJSON {
+			return fmt.Errorf("history repair only supports the json storage backend, MINYLS_STORAGE_BACKEND is '%s'",
+				e.StorageBackend)
+		}
+
+		var msg string
+		msg, err = storage.RepairJSON()
+		if err != nil {
+			return fmt.Errorf("failed to repair data file: %w", err)
+		}
+
+		log.Log().Info().Str("func", "cli.History").Str("result", msg).Msg("repaired data file")
+
+		return printResult(messageResult(msg), outputPlain)
+	default:
+		return fmt.Errorf("unknown history option '%s'", os.Args[2])
+	}
+}
+
+const neededHistoryArgsLen = 3
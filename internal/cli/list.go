@@ -1,18 +1,33 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"net/url"
 	"os"
+	"os/signal"
 	"path"
+	"slices"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/devusSs/minyls/internal/log"
 	"github.com/devusSs/minyls/internal/storage"
+	"github.com/devusSs/minyls/internal/yourls"
 )
 
+// List prints the entries, optionally filtered and sorted, see entryFilter.
+// The columns of the table can be selected with --columns, see listColumns.
 func List() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
 	err := initialize()
 	if err != nil {
 		return fmt.Errorf("failed to initialize: %w", err)
@@ -20,43 +35,250 @@ func List() error {
 
 	log.Log().Debug().Str("func", "cli.List").Msg("initialized")
 
-	data, err := storage.Read()
+	args, _, err := parseListArgs("list", 0)
+	if err != nil {
+		return err
+	}
+
+	return listEntries(ctx, args)
+}
+
+// Search prints the entries matching the query, see matchesQuery.
+// It supports the same flags as List.
+func Search() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := initialize()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.Search").Msg("initialized")
+
+	args, positional, err := parseListArgs("search", neededSearchArgsLen)
+	if err != nil {
+		return err
+	}
+
+	args.filter.query = positional[0]
+
+	return listEntries(ctx, args)
+}
+
+const neededSearchArgsLen = 1
+
+type listArgs struct {
+	filter entryFilter
+	// columns are the columns of the table output.
+	columns []string
+	// check checks whether the objects and short links still exist.
+	check bool
+}
+
+// parseListArgs parses the list flags and expects n positional arguments.
+func parseListArgs(name string, n int) (*listArgs, []string, error) {
+	args := &listArgs{}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	args.filter.register(fs)
+	columns := fs.String("columns", strings.Join(defaultListColumns, ","),
+		"comma separated columns of the table ("+strings.Join(slices.Sorted(maps.Keys(listColumns)), ", ")+")")
+	fs.BoolVar(&args.check, "check", false, "mark entries whose object or short link no longer exists as orphaned")
+
+	positional, err := parseFlags(fs, os.Args[2:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(positional) != n {
+		return nil, nil, fmt.Errorf("expected %d arguments, got %d", n, len(positional))
+	}
+
+	err = args.filter.validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, column := range strings.Split(*columns, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := listColumns[column]; !ok {
+			return nil, nil, fmt.Errorf("unknown column '%s' provided", column)
+		}
+
+		args.columns = append(args.columns, column)
+	}
+
+	return args, positional, nil
+}
+
+func listEntries(ctx context.Context, args *listArgs) error {
+	candidates, err := findEntries(&args.filter)
 	if err != nil {
 		return fmt.Errorf("failed to read storage: %w", err)
 	}
 
-	log.Log().Debug().Str("func", "cli.List").Any("data", data).Msg("read data from storage")
+	log.Log().Debug().Str("func", "cli.listEntries").Int("candidates", len(candidates)).Msg("read entries from storage")
 
-	if len(data.Entries) == 0 {
-		fmt.Println("NO DATA TO BE DISPLAYED")
-		return nil
+	entries := args.filter.apply(candidates)
+
+	values := make([]*entryOutput, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, newEntryOutput(entry))
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
-	fmt.Fprintln(w, "ID\tTimestamp\tMinio ID\tYOURLS ID\tExpiry")
+	if args.check {
+		err = checkEntries(ctx, values)
+		if err != nil {
+			return err
+		}
+	}
 
-	for _, entry := range data.Entries {
-		var minioURL *url.URL
-		var yourlsURL *url.URL
+	return printResult(&result{
+		value: values,
+		table: func(w io.Writer) error { return writeListTable(w, values, args.columns) },
+		plain: func(w io.Writer) error {
+			for _, entry := range entries {
+				fmt.Fprintf(w, "%d\t%s\n", entry.ID, entry.YOURLSLink)
+			}
 
-		minioURL, err = url.Parse(entry.MinioLink)
+			return nil
+		},
+	}, outputTable)
+}
+
+// findEntries returns the candidates for filter, using the tag
+// and expiry indexes of the store instead of reading all entries.
+func findEntries(filter *entryFilter) ([]*storage.DataEntry, error) {
+	switch {
+	case len(filter.tags) > 0:
+		return st.FindByTag(filter.tags[0])
+	case filter.expiringWithin > 0:
+		return st.FindExpiringBefore(time.Now().Add(time.Duration(filter.expiringWithin)))
+	default:
+		data, err := st.Read()
 		if err != nil {
-			return fmt.Errorf("failed to parse minio url: %w", err)
+			return nil, err
+		}
+
+		return data.Entries, nil
+	}
+}
+
+// checkEntries marks entries whose object or short link no longer exists as orphaned.
+// Storage only knows whether an entry references an object, see storage.DataEntry.Status.
+func checkEntries(ctx context.Context, outputs []*entryOutput) error {
+	mc, err := newMinioClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	var yc *yourls.Client
+	if e.ShortenerEnabled() {
+		yc = yourls.NewClient(e.YOURLSEndpoint, e.YOURLSSignature)
+	}
+
+	for _, o := range outputs {
+		if o.Status == storage.StatusDeleted || o.Status == storage.StatusOrphaned {
+			continue
 		}
 
-		yourlsURL, err = url.Parse(entry.YOURLSLink)
+		exists, err := mc.ObjectExists(ctx, o.Bucket, o.Object)
 		if err != nil {
-			return fmt.Errorf("failed to parse yourls url: %w", err)
+			return fmt.Errorf("could not check object of entry %d: %w", o.ID, err)
+		}
+
+		if exists && yc != nil && o.YOURLSLink != o.MinioLink {
+			exists, err = shortLinkExists(ctx, yc, o.DataEntry)
+			if err != nil {
+				return fmt.Errorf("could not check short link of entry %d: %w", o.ID, err)
+			}
+		}
+
+		if !exists {
+			log.Log().Debug().Str("func", "cli.checkEntries").Int("id", o.ID).Msg("entry is orphaned")
+			o.Status = storage.StatusOrphaned
+		}
+	}
+
+	return nil
+}
+
+func shortLinkExists(ctx context.Context, yc *yourls.Client, entry *storage.DataEntry) (bool, error) {
+	keyword, err := yourls.Keyword(entry.YOURLSLink)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = yc.Expand(ctx, keyword)
+	if errors.Is(err, yourls.ErrNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// listColumn is a column of the list table.
+type listColumn struct {
+	header string
+	value  func(o *entryOutput, now time.Time) string
+}
+
+// listColumns are the columns available via --columns.
+var listColumns = map[string]listColumn{
+	"id":     {"ID", func(o *entryOutput, _ time.Time) string { return strconv.Itoa(o.ID) }},
+	"name":   {"Name", func(o *entryOutput, _ time.Time) string { return o.OriginalName }},
+	"size":   {"Size", func(o *entryOutput, _ time.Time) string { return formatSize(o.Size) }},
+	"policy": {"Policy", func(o *entryOutput, _ time.Time) string { return o.Policy }},
+	"status": {"Status", func(o *entryOutput, _ time.Time) string { return o.Status }},
+	"created": {"Created", func(o *entryOutput, _ time.Time) string {
+		return o.Timestamp.Format(time.DateTime)
+	}},
+	"expires": {"Expires", func(o *entryOutput, _ time.Time) string {
+		return formatExpiresAt(o.ExpiresAt)
+	}},
+	"left": {"Left", func(o *entryOutput, now time.Time) string {
+		return formatTimeLeft(o.ExpiresAt, now)
+	}},
+	"expiry": {"Expiry", func(o *entryOutput, _ time.Time) string { return o.Expiry.String() }},
+	"link":   {"Link", func(o *entryOutput, _ time.Time) string { return o.YOURLSLink }},
+	"tags":   {"Tags", func(o *entryOutput, _ time.Time) string { return strings.Join(o.Tags, ",") }},
+	"minio_id": {"Minio ID", func(o *entryOutput, _ time.Time) string {
+		u, err := url.Parse(o.MinioLink)
+		if err != nil {
+			return ""
+		}
+
+		return path.Base(u.Path)
+	}},
+	"yourls_id": {"YOURLS ID", func(o *entryOutput, _ time.Time) string { return o.Keyword() }},
+}
+
+var defaultListColumns = []string{"id", "name", "size", "policy", "expires", "left", "status"}
+
+func writeListTable(out io.Writer, outputs []*entryOutput, columns []string) error {
+	if len(outputs) == 0 {
+		fmt.Fprintln(out, "NO DATA TO BE DISPLAYED")
+		return nil
+	}
+
+	now := time.Now()
+	row := make([]string, len(columns))
+
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+
+	for i, column := range columns {
+		row[i] = listColumns[column].header
+	}
+
+	fmt.Fprintln(w, strings.Join(row, "\t"))
+
+	for _, o := range outputs {
+		for i, column := range columns {
+			row[i] = listColumns[column].value(o, now)
 		}
 
-		fmt.Fprintf(w,
-			"%d\t%s\t%s\t%s\t%s\n",
-			entry.ID,
-			entry.Timestamp.Format(time.DateTime),
-			path.Base(minioURL.Path),
-			path.Base(yourlsURL.Path),
-			entry.Expiry,
-		)
+		fmt.Fprintln(w, strings.Join(row, "\t"))
 	}
 
 	return w.Flush()
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// Output formats, selected with the global --output flag.
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+	outputPlain = "plain"
+)
+
+var outputFormats = []string{outputJSON, outputYAML, outputTable, outputPlain}
+
+// result is the result of a command in all output formats.
+type result struct {
+	// value is encoded for the json and yaml formats.
+	value any
+	// table and plain write the human readable formats, if one of
+	// them is nil the other one is used instead.
+	table func(w io.Writer) error
+	plain func(w io.Writer) error
+}
+
+// printResult writes r to stdout in the format selected with
+// --output or in defaultFormat if none was selected.
+func printResult(r *result, defaultFormat string) error {
+	format := global.output
+	if format == "" {
+		format = defaultFormat
+	}
+
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.value)
+	case outputYAML:
+		return writeYAML(os.Stdout, r.value)
+	case outputTable:
+		if r.table != nil {
+			return r.table(os.Stdout)
+		}
+
+		return r.plain(os.Stdout)
+	case outputPlain:
+		if r.plain != nil {
+			return r.plain(os.Stdout)
+		}
+
+		return r.table(os.Stdout)
+	default:
+		return fmt.Errorf("unknown output format '%s'", format)
+	}
+}
+
+// writeYAML writes v as yaml using the json field names and order.
+func writeYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not encode result: %w", err)
+	}
+
+	// json is valid yaml, decoding it into a node keeps the order of the fields
+	var node yaml.Node
+	err = yaml.Unmarshal(b, &node)
+	if err != nil {
+		return fmt.Errorf("could not convert result: %w", err)
+	}
+
+	resetYAMLStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2) //nolint:mnd // common yaml indentation
+
+	err = enc.Encode(&node)
+	if err != nil {
+		return fmt.Errorf("could not encode result: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// resetYAMLStyle removes the json (flow) style from node.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+func validateOutputFormat(format string) error {
+	if format != "" && !slices.Contains(outputFormats, format) {
+		return fmt.Errorf("unknown output format '%s' (expected one of %v)", format, outputFormats)
+	}
+
+	return nil
+}
+
+// entryOutput adds computed fields to the json and yaml output of an entry.
+type entryOutput struct {
+	*storage.DataEntry
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	Status    string    `json:"status"`
+	Policy    string    `json:"policy,omitempty"`
+}
+
+func newEntryOutput(entry *storage.DataEntry) *entryOutput {
+	return &entryOutput{
+		DataEntry: entry,
+		ExpiresAt: entry.ExpiresAt(),
+		Status:    entry.Status(time.Now()),
+		Policy:    entry.Policy(),
+	}
+}
+
+// entryResult returns the result of commands creating or changing an entry.
+func entryResult(entry *storage.DataEntry) *result {
+	return &result{
+		value: newEntryOutput(entry),
+		table: func(w io.Writer) error {
+			tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+			fmt.Fprintf(tw, "ID\t%d\n", entry.ID)
+			fmt.Fprintf(tw, "Link\t%s\n", entry.YOURLSLink)
+			fmt.Fprintf(tw, "Name\t%s\n", entry.OriginalName)
+			fmt.Fprintf(tw, "Title\t%s\n", entry.Title)
+			fmt.Fprintf(tw, "Notes\t%s\n", entry.Notes)
+			fmt.Fprintf(tw, "Size\t%s\n", formatSize(entry.Size))
+			fmt.Fprintf(tw, "SHA256\t%s\n", entry.SHA256)
+			fmt.Fprintf(tw, "Bucket\t%s\n", entry.Bucket)
+			fmt.Fprintf(tw, "Expires\t%s\n", formatExpiresAt(entry.ExpiresAt()))
+			fmt.Fprintf(tw, "Tags\t%s\n", strings.Join(entry.Tags, ","))
+			return tw.Flush()
+		},
+		plain: func(w io.Writer) error {
+			link, err := formatLink(entry)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(w, link)
+			return err
+		},
+	}
+}
+
+// formatSize formats size in bytes using binary units, e.g. '1.5 MiB'.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// formatExpiresAt formats the expiry time t of an entry,
+// which is the zero time if the entry never expires.
+func formatExpiresAt(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	return t.Format(time.DateTime)
+}
+
+// formatTimeLeft formats the time until t in its two largest units,
+// e.g. '6d 23h' or '12m', '-' if t has passed and 'never' if t is zero.
+func formatTimeLeft(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	left := t.Sub(now)
+	if left <= 0 {
+		return "-"
+	}
+
+	days := int(left / day)
+	hours := int(left % day / time.Hour)
+	minutes := int(left % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return "<1m"
+	}
+}
+
+// messageResult returns the result of commands only reporting a message.
+func messageResult(msg string) *result {
+	return &result{
+		value: map[string]string{"message": msg},
+		plain: func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, msg)
+			return err
+		},
+	}
+}
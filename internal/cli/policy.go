@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// Policy moves the object of an existing entry into the bucket of the
+// provided policy, regenerates its link and repoints the short link.
+func Policy() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := initialize()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.Policy").Msg("initialized")
+
+	fs := flag.NewFlagSet("policy", flag.ContinueOnError)
+	newLink := fs.Bool("new-link", false, newLinkUsage)
+
+	positional, err := parseFlags(fs, os.Args[2:])
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != neededPolicyArgsLen {
+		return fmt.Errorf("expected %d arguments, got %d", neededPolicyArgsLen, len(positional))
+	}
+
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	p, err := getUploadPolicy(positional[1])
+	if err != nil {
+		return fmt.Errorf("could not get policy: %w", err)
+	}
+
+	entry, err := st.FindByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to find entry %d: %w", id, err)
+	}
+
+	err = checkPolicyChange(entry, p)
+	if err != nil {
+		return err
+	}
+
+	bucket, key, err := entryObject(entry)
+	if err != nil {
+		return fmt.Errorf("could not get object of entry %d: %w", id, err)
+	}
+
+	mc, err := newMinioClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the object may have been moved by a previous run
+	// which failed to update the link afterwards
+	if bucket != mc.Bucket(p == "public") {
+		bucket, err = moveEntryObject(ctx, mc, id, bucket, key, p == "public")
+		if err != nil {
+			return err
+		}
+	}
+
+	// the gateway resolves the bucket per request,
+	// so gateway links stay the same
+	link := entry.MinioLink
+	if entry.Token == "" {
+		link, err = mc.Link(ctx, bucket, key, entry.Expiry)
+		if err != nil {
+			return fmt.Errorf("could not create link: %w", err)
+		}
+	}
+
+	shortLink, err := repointShortLink(ctx, entry, link, *newLink)
+	if err != nil {
+		return fmt.Errorf("could not repoint short link: %w", err)
+	}
+
+	entry, err = st.UpdateEntry(id, func(entry *storage.DataEntry) error {
+		// only new presigned links start a new expiry
+		if link != entry.MinioLink {
+			entry.Timestamp = time.Now()
+		}
+
+		entry.MinioLink = link
+		entry.YOURLSLink = shortLink
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update entry %d: %w", id, err)
+	}
+
+	log.Log().Info().Str("func", "cli.Policy").Any("entry", entry).Msg("updated entry")
+
+	copyToClipboard(entry)
+
+	return printResult(entryResult(entry), outputPlain)
+}
+
+const neededPolicyArgsLen = 2
+
+// moveEntryObject moves the object of the entry with id into the bucket of
+// the policy and stores the new bucket right away, so the entry never
+// references a missing object even if updating its link fails later.
+func moveEntryObject(
+	ctx context.Context,
+	mc *minio.Client,
+	id int,
+	bucket string,
+	key string,
+	public bool,
+) (string, error) {
+	bucket, err := mc.Move(ctx, bucket, key, public)
+	if err != nil {
+		return "", fmt.Errorf("could not move object: %w", err)
+	}
+
+	log.Log().Info().Str("func", "cli.moveEntryObject").Str("bucket", bucket).Str("object", key).Msg("moved object")
+
+	_, err = st.UpdateEntry(id, func(entry *storage.DataEntry) error {
+		entry.Bucket = bucket
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to update bucket of entry %d after moving its object to '%s': %w", id, bucket, err)
+	}
+
+	return bucket, nil
+}
+
+func checkPolicyChange(entry *storage.DataEntry, p string) error {
+	if entry.Deleted {
+		return fmt.Errorf("object of entry %d has been deleted", entry.ID)
+	}
+
+	// public objects can be downloaded without the gateway
+	if p == "public" && (entry.Protected || entry.MaxDownloads > 0) {
+		return errors.New("entries with a password or download limit need to stay 'private'")
+	}
+
+	// presigned urls cannot be created without an expiry
+	if p == "private" && entry.Token == "" && entry.NeverExpires() {
+		return errors.New("entries which never expire need to be served via the gateway to be 'private'")
+	}
+
+	return nil
+}
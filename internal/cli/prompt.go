@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// stdin is shared by all prompts so buffered input is not lost between them.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassword prompts for a password on stderr and reads it from stdin.
+// The input is not echoed if stdin is a terminal.
+func readPassword(prompt string) (string, error) {
+	pw, err := readInput(prompt, true)
+	if err != nil {
+		return "", fmt.Errorf("could not read password: %w", err)
+	}
+
+	if pw == "" {
+		return "", errors.New("empty password provided")
+	}
+
+	return pw, nil
+}
+
+// readLine prompts on stderr and reads a line from stdin.
+func readLine(prompt string) (string, error) {
+	return readInput(prompt, false)
+}
+
+// readInput prompts on stderr and reads a line from stdin.
+// The input is not echoed if hidden is set and stdin is a terminal.
+func readInput(prompt string, hidden bool) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	fd := int(os.Stdin.Fd()) //nolint:gosec // file descriptors fit into int
+	if !hidden || !term.IsTerminal(fd) {
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// confirm asks a yes/no question, anything but 'y' or 'yes' means no.
+func confirm(prompt string) (bool, error) {
+	answer, err := readLine(prompt + " [y/N]: ")
+	if err != nil {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/qr"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// QR renders the short link of the entry with the provided id as QR code
+// in the terminal or writes it to a PNG or SVG file (--out).
+func QR() error {
+	err := initialize()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.QR").Msg("initialized")
+
+	fs := flag.NewFlagSet("qr", flag.ContinueOnError)
+	var opts qrOptions
+	opts.register(fs)
+
+	positional, err := parseFlags(fs, os.Args[2:])
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != neededQRArgsLen {
+		return fmt.Errorf("expected %d arguments, got %d", neededQRArgsLen, len(positional))
+	}
+
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	err = opts.validate()
+	if err != nil {
+		return err
+	}
+
+	entry, err := st.FindByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to find entry %d: %w", id, err)
+	}
+
+	opts.enabled = true
+	return opts.write(os.Stdout, entry)
+}
+
+const neededQRArgsLen = 1
+
+// qrOptions are the flags of commands rendering QR codes.
+type qrOptions struct {
+	enabled bool
+	// out is the PNG or SVG file to write, the terminal is used if empty.
+	out  string
+	size int
+}
+
+func (o *qrOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.out, "out", "", "write the qr code to a .png or .svg file instead of the terminal")
+	fs.IntVar(&o.size, "size", qr.DefaultSize, "width and height of png qr codes in pixels")
+}
+
+func (o *qrOptions) validate() error {
+	if o.size <= 0 {
+		return fmt.Errorf("invalid qr code size %d provided", o.size)
+	}
+
+	if o.out != "" {
+		return qr.CheckFile(o.out)
+	}
+
+	return nil
+}
+
+// write renders the short link of entry as QR code to w or the file.
+func (o *qrOptions) write(w io.Writer, entry *storage.DataEntry) error {
+	if !o.enabled {
+		return nil
+	}
+
+	if o.out == "" {
+		return qr.Terminal(w, entry.YOURLSLink)
+	}
+
+	err := qr.WriteFile(o.out, entry.YOURLSLink, o.size)
+	if err != nil {
+		return err
+	}
+
+	log.Log().Info().Str("func", "cli.qrOptions.write").Str("path", o.out).Msg("wrote qr code")
+
+	fmt.Fprintln(os.Stderr, "wrote qr code to", o.out)
+
+	return nil
+}
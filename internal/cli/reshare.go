@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/storage"
+	"github.com/devusSs/minyls/internal/yourls"
+)
+
+// Reshare regenerates the link of an existing entry with a new expiry.
+// The short link is kept if possible, so links already sent keep working.
+func Reshare() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := initialize()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.Reshare").Msg("initialized")
+
+	args, err := parseReshareArgs()
+	if err != nil {
+		return err
+	}
+
+	id, expiry, entry := args.id, args.expiry, args.entry
+
+	bucket, key, err := entryObject(entry)
+	if err != nil {
+		return fmt.Errorf("could not get object of entry %d: %w", id, err)
+	}
+
+	mc, err := newMinioClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	// gateway links are stable, only the expiry has to be updated
+	link := entry.MinioLink
+	if entry.Token == "" {
+		link, err = mc.Link(ctx, bucket, key, expiry)
+		if err != nil {
+			return fmt.Errorf("could not create link: %w", err)
+		}
+	}
+
+	shortLink, err := repointShortLink(ctx, entry, link, args.newLink)
+	if err != nil {
+		return fmt.Errorf("could not repoint short link: %w", err)
+	}
+
+	entry, err = st.UpdateEntry(id, func(entry *storage.DataEntry) error {
+		entry.Bucket = bucket
+		entry.Object = key
+		entry.MinioLink = link
+		entry.YOURLSLink = shortLink
+		entry.Timestamp = time.Now()
+		entry.Expiry = expiry
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update entry %d: %w", id, err)
+	}
+
+	log.Log().Info().Str("func", "cli.Reshare").Any("entry", entry).Msg("updated entry")
+
+	copyToClipboard(entry)
+
+	return printResult(entryResult(entry), outputPlain)
+}
+
+type reshareArgs struct {
+	id      int
+	expiry  time.Duration
+	newLink bool
+	// entry is needed to validate the expiry.
+	entry *storage.DataEntry
+}
+
+func parseReshareArgs() (*reshareArgs, error) {
+	args := &reshareArgs{}
+
+	expiry := durationFlag(e.MinioLinkExpiry)
+
+	fs := flag.NewFlagSet("reshare", flag.ContinueOnError)
+	fs.Var(&expiry, "expiry", "expiry of the regenerated link, e.g. '3d' (negative to never expire)")
+	fs.BoolVar(&args.newLink, "new-link", false, newLinkUsage)
+
+	positional, err := parseFlags(fs, os.Args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(positional) != neededReshareArgsLen {
+		return nil, fmt.Errorf("expected %d arguments, got %d", neededReshareArgsLen, len(positional))
+	}
+
+	args.id, err = parseID(positional[0])
+	if err != nil {
+		return nil, err
+	}
+
+	args.entry, err = st.FindByID(args.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find entry %d: %w", args.id, err)
+	}
+
+	if args.entry.Deleted {
+		return nil, fmt.Errorf("object of entry %d has been deleted", args.id)
+	}
+
+	args.expiry = time.Duration(expiry)
+
+	err = checkReshareExpiry(args.entry, args.expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
+
+// checkReshareExpiry checks expiry against the limits of the link of entry.
+// Only presigned urls are limited, the gateway presigns per request and
+// public objects need no presigned url.
+func checkReshareExpiry(entry *storage.DataEntry, expiry time.Duration) error {
+	if expiry == 0 {
+		return fmt.Errorf("invalid expiry '%s' provided", expiry)
+	}
+
+	if entry.Token != "" || entry.Policy() != "private" {
+		return nil
+	}
+
+	if expiry < 0 {
+		return errors.New("presigned links need an expiry, private entries only never expire via the gateway")
+	}
+
+	if expiry > minio.MaxPresignExpiry {
+		return fmt.Errorf("expiry of %s exceeds the presign limit of %s", expiry, minio.MaxPresignExpiry)
+	}
+
+	return nil
+}
+
+const neededReshareArgsLen = 1
+
+const newLinkUsage = "create a new short link if the existing one cannot be updated (the old one stops working)"
+
+// repointShortLink points the short link of entry to link and returns the short link.
+// If it cannot be updated (e.g. the YOURLS instance does not support updating links),
+// an error is returned unless newLink allows creating a new short link instead.
+func repointShortLink(ctx context.Context, entry *storage.DataEntry, link string, newLink bool) (string, error) {
+	if link == entry.MinioLink {
+		return entry.YOURLSLink, nil
+	}
+
+	if !e.ShortenerEnabled() {
+		return link, nil
+	}
+
+	yc := yourls.NewClient(e.YOURLSEndpoint, e.YOURLSSignature)
+
+	keyword, err := yourls.Keyword(entry.YOURLSLink)
+	if err == nil {
+		err = yc.Update(ctx, keyword, link, yourlsTitle(entry))
+	}
+
+	if err == nil {
+		log.Log().Info().Str("func", "cli.repointShortLink").Str("keyword", keyword).Msg("updated short link")
+		return entry.YOURLSLink, nil
+	}
+
+	if !newLink {
+		return "", fmt.Errorf("could not update short link (use --new-link to create a new one): %w", err)
+	}
+
+	log.Log().
+		Warn().
+		Err(err).
+		Str("func", "cli.repointShortLink").
+		Msg("could not update short link, creating a new one")
+
+	fmt.Fprintf(os.Stderr, "warning: could not update short link, created a new one, %s no longer works: %v\n",
+		entry.YOURLSLink, err)
+
+	return shorten(ctx, link, yourlsTitle(entry))
+}
+
+// entryObject returns the bucket and key of the object of entry.
+func entryObject(entry *storage.DataEntry) (string, string, error) {
+	if entry.Bucket == "" || entry.Object == "" {
+		return "", "", errors.New("entry does not reference an object")
+	}
+
+	return entry.Bucket, entry.Object, nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// Revoke revokes the entry with the provided id so the gateway
+// no longer serves it. The object itself is kept. Public entries
+// cannot be revoked since their object url stays readable.
+func Revoke() error {
+	err := initialize()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.Revoke").Msg("initialized")
+
+	if len(os.Args) != neededRevokeArgsLen {
+		return fmt.Errorf("expected %d arguments, got %d", neededRevokeArgsLen, len(os.Args))
+	}
+
+	id, err := parseID(os.Args[2])
+	if err != nil {
+		return err
+	}
+
+	entry, err := st.UpdateEntry(id, func(entry *storage.DataEntry) error {
+		if entry.Token == "" {
+			return errors.New("entry is not served via the gateway")
+		}
+
+		// the gateway redirects public entries to the permanent
+		// object url, so revoking them would not stop any access
+		if entry.Policy() == "public" {
+			return fmt.Errorf("objects of public entries stay readable, run 'minyls policy %d private' first", id)
+		}
+
+		entry.Revoked = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke entry %d: %w", id, err)
+	}
+
+	log.Log().Info().Str("func", "cli.Revoke").Any("entry", entry).Msg("revoked entry")
+
+	return printResult(entryResult(entry), outputPlain)
+}
+
+const neededRevokeArgsLen = 3
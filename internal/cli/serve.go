@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/devusSs/minyls/internal/gateway"
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+func Serve() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := initialize()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.Serve").Msg("initialized")
+
+	mc, err := newMinioClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	uploadFn := func(ctx context.Context, filePath string, name string, public bool) (*storage.DataEntry, error) {
+		policy := "private"
+		if public {
+			policy = "public"
+		}
+
+		return upload(ctx, mc, &uploadArgs{filePath: filePath, name: name, policy: policy}, nil)
+	}
+
+	return gateway.New(
+		mc,
+		st,
+		e.GatewayAddress,
+		gateway.WithUpload(e.GatewayUploadToken, uploadFn),
+	).ListenAndServe(ctx)
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/devusSs/minyls/internal/gateway"
+	"github.com/devusSs/minyls/internal/log"
+)
+
+// ShareX writes a ShareX custom uploader config (.sxcu) for the
+// upload endpoint of the gateway. name is the name of the uploader.
+func ShareX(name string) error {
+	err := initialize()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Log().Debug().Str("func", "cli.ShareX").Msg("initialized")
+
+	if len(os.Args) > maxShareXArgsLen {
+		return fmt.Errorf("expected at most %d arguments, got %d", maxShareXArgsLen, len(os.Args))
+	}
+
+	fp := defaultShareXFilePath
+	if len(os.Args) == maxShareXArgsLen {
+		fp = os.Args[2]
+	}
+
+	if e.GatewayURL == "" || e.GatewayUploadToken == "" {
+		return errors.New("sharex config needs MINYLS_GATEWAY_URL and MINYLS_GATEWAY_UPLOAD_TOKEN to be set")
+	}
+
+	u, err := url.Parse(e.GatewayURL)
+	if err != nil {
+		return fmt.Errorf("invalid gateway url: %w", err)
+	}
+
+	cfg := &shareXConfig{
+		Version:         shareXVersion,
+		Name:            name,
+		DestinationType: "ImageUploader, TextUploader, FileUploader",
+		RequestMethod:   "POST",
+		RequestURL:      u.JoinPath(gateway.UploadPath).String(),
+		Headers:         map[string]string{"Authorization": "Bearer " + e.GatewayUploadToken},
+		Body:            "MultipartFormData",
+		FileFormName:    gateway.UploadFormField,
+		URL:             "{json:url}",
+		ErrorMessage:    "{json:error}",
+	}
+
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal sharex config: %w", err)
+	}
+
+	// the config contains the upload token
+	err = os.WriteFile(fp, b, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write sharex config: %w", err)
+	}
+
+	log.Log().Info().Str("func", "cli.ShareX").Str("file_path", fp).Msg("wrote sharex config")
+
+	return printResult(&result{
+		value: map[string]string{"path": fp},
+		plain: func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, "wrote sharex config to", fp)
+			return err
+		},
+	}, outputPlain)
+}
+
+const (
+	maxShareXArgsLen      = 3
+	defaultShareXFilePath = "minyls.sxcu"
+	shareXVersion         = "17.0.0"
+)
+
+// shareXConfig is the custom uploader config format of ShareX.
+type shareXConfig struct {
+	Version         string            `json:"Version"`
+	Name            string            `json:"Name"`
+	DestinationType string            `json:"DestinationType"`
+	RequestMethod   string            `json:"RequestMethod"`
+	RequestURL      string            `json:"RequestURL"`
+	Headers         map[string]string `json:"Headers"`
+	Body            string            `json:"Body"`
+	FileFormName    string            `json:"FileFormName"`
+	URL             string            `json:"URL"`
+	ErrorMessage    string            `json:"ErrorMessage"`
+}
@@ -2,15 +2,21 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"time"
 
-	"github.com/devusSs/minyls/internal/clip"
+	"github.com/devusSs/minyls/internal/gateway"
 	"github.com/devusSs/minyls/internal/log"
 	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/qr"
 	"github.com/devusSs/minyls/internal/storage"
 	"github.com/devusSs/minyls/internal/yourls"
 )
@@ -26,102 +32,258 @@ func Upload() error {
 
 	log.Log().Debug().Str("func", "cli.Upload").Msg("initialized")
 
-	if len(os.Args) != neededUploadArgsLen {
-		return fmt.Errorf("expected %d arguments, got %d", neededUploadArgsLen, len(os.Args))
+	args, err := parseUploadArgs()
+	if err != nil {
+		return err
 	}
 
-	log.Log().Debug().Str("func", "cli.Upload").Int("len_args", len(os.Args)).Msg("got os args")
+	log.Log().
+		Info().
+		Str("func", "cli.Upload").
+		Str("file_path", args.filePath).
+		Str("policy", args.policy).
+		Bool("password", args.password).
+		Int("max_downloads", args.maxDownloads).
+		Strs("tags", args.tags).
+		Msg("got args")
 
-	fp, err := getUploadFilePath()
+	metadata, err := getUploadMetadata(args.password)
 	if err != nil {
-		return fmt.Errorf("could not get upload file path: %w", err)
+		return fmt.Errorf("could not get upload metadata: %w", err)
 	}
 
-	log.Log().Info().Str("func", "cli.Upload").Str("file_path", fp).Msg("got file path")
+	mc, err := newMinioClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	p, err := getUploadPolicy()
+	entry, err := upload(ctx, mc, args, metadata)
 	if err != nil {
-		return fmt.Errorf("could not get upload policy: %w", err)
+		return err
 	}
 
-	log.Log().Info().Str("func", "cli.Upload").Str("policy", p).Msg("got policy")
+	copyToClipboard(entry)
 
-	mc, err := minio.NewClient(e.MinioEndpoint, e.MinioAccessKey, e.MinioAccessSecret)
+	err = printResult(entryResult(entry), outputPlain)
 	if err != nil {
-		return fmt.Errorf("could not create minio client: %w", err)
+		return err
 	}
 
-	log.Log().
-		Debug().
-		Str("func", "cli.Upload").
-		Str("endpoint", e.MinioEndpoint).
-		Str("access_key", e.MinioAccessKey).
-		Str("access_secret", e.MinioAccessSecret).
-		Msg("created minio client")
+	// stdout only contains the result so it can still be used by scripts,
+	// since the upload succeeded, failing to render is only a warning
+	err = args.qr.write(os.Stderr, entry)
+	if err != nil {
+		log.Log().Warn().Err(err).Str("func", "cli.Upload").Msg("could not render qr code")
+		fmt.Fprintln(os.Stderr, "warning: could not render qr code:", err)
+	}
+
+	return nil
+}
 
-	err = mc.Setup(ctx, e.MinioBucketName, e.MinioRegion)
+// upload uploads the file described by args to minio, creates the link,
+// shortens it and stores the resulting entry. It is shared by the upload
+// command and the gateway upload endpoint.
+func upload(
+	ctx context.Context,
+	mc *minio.Client,
+	args *uploadArgs,
+	metadata map[string]string,
+) (*storage.DataEntry, error) {
+	hash, err := fileSHA256(args.filePath)
 	if err != nil {
-		return fmt.Errorf("could not setup minio client: %w", err)
+		return nil, fmt.Errorf("could not hash file: %w", err)
+	}
+
+	res, err := mc.Upload(ctx, args.filePath, minio.UploadOptions{
+		Public:   args.policy == "public",
+		Metadata: metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not upload file to minio: %w", err)
 	}
 
 	log.Log().
-		Debug().
-		Str("func", "cli.Upload").
-		Str("bucket_name", e.MinioBucketName).
-		Str("region", e.MinioRegion).
-		Msg("setup minio client")
+		Info().
+		Str("func", "cli.upload").
+		Str("bucket", res.Bucket).
+		Str("object", res.Key).
+		Msg("uploaded file to minio")
+
+	entry := &storage.DataEntry{
+		Timestamp:    time.Now(),
+		Expiry:       e.MinioLinkExpiry,
+		Bucket:       res.Bucket,
+		Object:       res.Key,
+		OriginalName: args.name,
+		Size:         res.Size,
+		SHA256:       hash,
+		Title:        args.title,
+		Notes:        args.notes,
+		Tags:         args.tags,
+		Protected:    args.password,
+		MaxDownloads: args.maxDownloads,
+	}
 
-	minioLink, err := mc.Upload(ctx, fp, p == "public", e.MinioLinkExpiry)
+	err = createUploadLink(ctx, mc, entry, args.viaGateway())
 	if err != nil {
-		return fmt.Errorf("could not upload file to minio: %w", err)
+		return nil, fmt.Errorf("could not create link: %w", err)
 	}
 
 	log.Log().
 		Info().
-		Str("func", "cli.Upload").
-		Str("minio_link'", minioLink).
-		Msg("got minio presigned url")
+		Str("func", "cli.upload").
+		Str("minio_link", entry.MinioLink).
+		Msg("got minio link")
 
-	yc := yourls.NewClient(e.YOURLSEndpoint, e.YOURLSSignature)
-	link, err := yc.Shorten(ctx, minioLink, e.YOURLSTitle)
+	entry.YOURLSLink, err = shorten(ctx, entry.MinioLink, yourlsTitle(entry))
 	if err != nil {
-		return fmt.Errorf("could not shorten url: %w", err)
+		return nil, fmt.Errorf("could not shorten url: %w", err)
 	}
 
 	log.Log().
 		Info().
-		Str("func", "cli.Upload").
-		Str("yourls_link", link).
+		Str("func", "cli.upload").
+		Str("yourls_link", entry.YOURLSLink).
 		Msg("got shortened yourls link")
 
-	entry := &storage.DataEntry{
-		Timestamp:  time.Now(),
-		MinioLink:  minioLink,
-		YOURLSLink: link,
-		Expiry:     e.MinioLinkExpiry,
+	err = st.WriteEntry(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write entry to storage: %w", err)
+	}
+
+	log.Log().Info().Str("func", "cli.upload").Any("entry", entry).Msg("wrote entry to storage")
+
+	return entry, nil
+}
+
+// shorten shortens link using YOURLS. Without a shortener
+// configured the link is returned as is.
+func shorten(ctx context.Context, link string, title string) (string, error) {
+	if !e.ShortenerEnabled() {
+		return link, nil
 	}
 
-	err = storage.WriteEntry(entry)
+	yc := yourls.NewClient(e.YOURLSEndpoint, e.YOURLSSignature)
+	return yc.Shorten(ctx, link, title)
+}
+
+// yourlsTitle returns the title of the short link of entry.
+func yourlsTitle(entry *storage.DataEntry) string {
+	if entry.Title != "" {
+		return entry.Title
+	}
+
+	return e.YOURLSTitle
+}
+
+const neededUploadArgsLen = 2
+
+type uploadArgs struct {
+	filePath string
+	// name is the original name of the file.
+	name         string
+	policy       string
+	password     bool
+	maxDownloads int
+	title        string
+	notes        string
+	tags         stringsFlag
+	// qr renders the short link as QR code after the upload.
+	qr qrOptions
+}
+
+// viaGateway reports whether the upload should be served via the gateway.
+// All uploads use the gateway once it is configured, uploads with access
+// rules need the gateway to enforce them.
+func (a *uploadArgs) viaGateway() bool {
+	return e.GatewayURL != "" || a.needsGateway()
+}
+
+func (a *uploadArgs) needsGateway() bool {
+	return a.password || a.maxDownloads > 0
+}
+
+func parseUploadArgs() (*uploadArgs, error) {
+	args := &uploadArgs{}
+
+	fs := flag.NewFlagSet("upload", flag.ContinueOnError)
+	fs.BoolVar(&args.password, "password", false, "require a password to download the file (needs the gateway)")
+	fs.IntVar(&args.maxDownloads, "max-downloads", 0,
+		"delete the file after this many downloads (needs the gateway, the last download may be repeated for 30s)")
+	once := fs.Bool("once", false,
+		"delete the file after the first download (needs the gateway, it may be repeated for 30s)")
+	fs.Var(&args.tags, "tag", "tag the upload, may be provided multiple times")
+	fs.StringVar(&args.title, "title", "", "title of the upload, also used for the short link")
+	fs.StringVar(&args.notes, "note", "", "notes about the upload, e.g. who it was shared with")
+	fs.BoolVar(&args.qr.enabled, "qr", false, "render the link as qr code in the terminal")
+	fs.StringVar(&args.qr.out, "qr-out", "", "write the link as qr code to a .png or .svg file")
+	fs.IntVar(&args.qr.size, "qr-size", qr.DefaultSize, "width and height of png qr codes in pixels")
+
+	positional, err := parseFlags(fs, os.Args[2:])
 	if err != nil {
-		return fmt.Errorf("failed to write entry to storage: %w", err)
+		return nil, err
 	}
 
-	log.Log().Info().Str("func", "cli.Upload").Any("entry", entry).Msg("wrote entry to storage")
+	// fail before uploading instead of when printing the link
+	_, err = linkTemplate(linkFormat())
+	if err != nil {
+		return nil, err
+	}
 
-	err = clip.Write(link)
+	if len(positional) != neededUploadArgsLen {
+		return nil, fmt.Errorf("expected %d arguments, got %d", neededUploadArgsLen, len(positional))
+	}
+
+	args.filePath, err = getUploadFilePath(positional[0])
 	if err != nil {
-		return fmt.Errorf("could not write link to clip: %w", err)
+		return nil, fmt.Errorf("could not get upload file path: %w", err)
 	}
 
-	log.Log().Info().Str("func", "cli.Upload").Str("link", link).Msg("wrote link to clip")
+	args.name = filepath.Base(args.filePath)
 
-	return nil
-}
+	args.policy, err = getUploadPolicy(positional[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not get upload policy: %w", err)
+	}
+
+	if args.qr.out != "" {
+		args.qr.enabled = true
+	}
+
+	err = args.qr.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if *once {
+		args.maxDownloads = 1
+	}
+
+	if args.maxDownloads < 0 {
+		return nil, fmt.Errorf("invalid max downloads %d provided", args.maxDownloads)
+	}
+
+	// presigned urls cannot be created without an expiry
+	if args.policy == "private" && !args.viaGateway() && e.MinioLinkExpiry < 0 {
+		return nil, errors.New("private uploads which never expire need MINYLS_GATEWAY_URL to be set")
+	}
+
+	if !args.needsGateway() {
+		return args, nil
+	}
+
+	if args.policy != "private" {
+		return nil, errors.New("uploads with a password or download limit need to be 'private'")
+	}
 
-const neededUploadArgsLen = 4
+	if e.GatewayURL == "" {
+		return nil, errors.New("uploads with a password or download limit need MINYLS_GATEWAY_URL to be set")
+	}
+
+	return args, nil
+}
 
-func getUploadFilePath() (string, error) {
-	fp := os.Args[2]
+func getUploadFilePath(fp string) (string, error) {
 	if fp == "" {
 		return "", errors.New("empty filepath provided")
 	}
@@ -134,11 +296,63 @@ func getUploadFilePath() (string, error) {
 	return fp, nil
 }
 
-func getUploadPolicy() (string, error) {
-	p := os.Args[3]
+func getUploadPolicy(p string) (string, error) {
 	if p != "public" && p != "private" {
 		return "", fmt.Errorf("unexpected policy '%s' provided (expected 'public' or 'private')", p)
 	}
 
 	return p, nil
 }
+
+// getUploadMetadata returns the object metadata for the upload.
+// Password protected uploads store the password hash on the object.
+func getUploadMetadata(password bool) (map[string]string, error) {
+	if !password {
+		return nil, nil //nolint:nilnil // no metadata is a valid result
+	}
+
+	pw, err := readPassword("Password: ")
+	if err != nil {
+		return nil, fmt.Errorf("could not read password: %w", err)
+	}
+
+	hash, err := gateway.HashPassword(pw)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash password: %w", err)
+	}
+
+	return map[string]string{gateway.PasswordMetadataKey: hash}, nil
+}
+
+func fileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// createUploadLink sets the link which should be shortened on entry.
+// Entries served via the gateway get a stable gateway link,
+// all other entries get a link directly to the object.
+func createUploadLink(ctx context.Context, mc *minio.Client, entry *storage.DataEntry, viaGateway bool) error {
+	var err error
+
+	if !viaGateway {
+		entry.MinioLink, err = mc.Link(ctx, entry.Bucket, entry.Object, entry.Expiry)
+		return err
+	}
+
+	entry.Token = gateway.NewToken()
+	entry.MinioLink, err = gateway.Link(e.GatewayURL, entry.Token)
+	return err
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/devusSs/minyls/internal/clip"
+	"github.com/devusSs/minyls/internal/env"
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// validateEnv checks the values of ev, including the ones
+// depending on other packages, and returns all problems found.
+func validateEnv(ev *env.Env) error {
+	errs := []error{ev.Validate()}
+
+	// the gateway creates short lived presigned urls per request
+	if ev.GatewayURL == "" && ev.MinioLinkExpiry > minio.MaxPresignExpiry {
+		errs = append(errs, fmt.Errorf(
+			"MINYLS_MINIO_LINK_EXPIRY of %s exceeds the presign limit of %s, set MINYLS_GATEWAY_URL to use longer expiries",
+			ev.MinioLinkExpiry,
+			minio.MaxPresignExpiry,
+		))
+	}
+
+	if ev.StorageBackend != storage.BackendBolt && ev.StorageBackend != storage.BackendJSON {
+		errs = append(errs, fmt.Errorf("MINYLS_STORAGE_BACKEND must be 'bolt' or 'json', got '%s'", ev.StorageBackend))
+	}
+
+	clipboards := append([]string{clip.Auto, clip.On, clipboardOff}, clip.Names()...)
+	if !slices.Contains(clipboards, ev.Clipboard) {
+		errs = append(errs, fmt.Errorf("MINYLS_CLIPBOARD must be one of %s, got '%s'",
+			strings.Join(clipboards, ", "), ev.Clipboard))
+	}
+
+	if _, err := linkTemplate(ev.Format); ev.Format != "" && err != nil {
+		errs = append(errs, fmt.Errorf("MINYLS_FORMAT is invalid: %w", err))
+	}
+
+	if !slices.Contains(log.Formats, ev.LogFormat) {
+		errs = append(errs, fmt.Errorf("MINYLS_LOG_FORMAT must be one of %v, got '%s'", log.Formats, ev.LogFormat))
+	}
+
+	if !slices.Contains(log.Destinations, ev.LogDestination) {
+		errs = append(errs, fmt.Errorf(
+			"MINYLS_LOG_DESTINATION must be one of %v, got '%s'",
+			log.Destinations,
+			ev.LogDestination,
+		))
+	}
+
+	return errors.Join(errs...)
+}
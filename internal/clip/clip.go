@@ -1,24 +1,77 @@
 package clip
 
 import (
+	"errors"
 	"fmt"
-	"runtime"
+	"strings"
+)
+
+// Backend writes text to a clipboard.
+type Backend interface {
+	Name() string
+	// Available returns an error if the backend cannot be used.
+	Available() error
+	Write(text string) error
+}
 
-	"github.com/atotto/clipboard"
+// Auto selects the first available backend, see Select. On is an
+// alias of Auto, it was the default of MINYLS_CLIPBOARD before.
+const (
+	Auto = "auto"
+	On   = "on"
 )
 
-func Init() error {
-	switch runtime.GOOS {
-	case "windows":
-	case "darwin":
-	case "linux":
-	default:
-		return fmt.Errorf("clip not implemented for '%s'", runtime.GOOS)
+// Names returns the names of all backends which may be selected.
+func Names() []string {
+	names := make([]string, 0, len(backends("")))
+	for _, b := range backends("") {
+		names = append(names, b.Name())
 	}
 
-	return nil
+	return names
 }
 
-func Write(input string) error {
-	return clipboard.WriteAll(input)
+// New returns the backend with the specified name or selects one
+// if name is Auto or On. command is used by the command backend.
+func New(name string, command string) (Backend, error) {
+	if name == Auto || name == On || name == "" {
+		return Select(command)
+	}
+
+	for _, b := range backends(command) {
+		if b.Name() == name {
+			return b, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown clipboard backend '%s' (expected one of %s)", name, strings.Join(Names(), ", "))
+}
+
+// Select returns the first available backend. The command backend is
+// preferred if a command is configured, OSC 52 is the last resort.
+func Select(command string) (Backend, error) {
+	var errs []error
+	for _, b := range backends(command) {
+		err := b.Available()
+		if err == nil {
+			return b, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+	}
+
+	return nil, fmt.Errorf("no clipboard available: %w", errors.Join(errs...))
+}
+
+// backends returns all backends in the order of selection.
+func backends(command string) []Backend {
+	return []Backend{
+		&commandBackend{name: "command", args: strings.Fields(command)},
+		&commandBackend{name: "wl-copy", args: []string{"wl-copy"}, env: "WAYLAND_DISPLAY"},
+		&commandBackend{name: "xclip", args: []string{"xclip", "-selection", "clipboard"}, env: "DISPLAY"},
+		&commandBackend{name: "xsel", args: []string{"xsel", "--clipboard", "--input"}, env: "DISPLAY"},
+		&commandBackend{name: "pbcopy", args: []string{"pbcopy"}},
+		&windowsBackend{},
+		&osc52Backend{},
+	}
 }
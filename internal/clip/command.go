@@ -0,0 +1,97 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// textPlaceholder is replaced by the text in the arguments
+// of a command, otherwise the text is written to its stdin.
+const textPlaceholder = "{}"
+
+// commandBackend runs a clipboard utility.
+type commandBackend struct {
+	name string
+	args []string
+	// env needs to be set for the utility to work, e.g. DISPLAY for xclip.
+	env string
+}
+
+func (b *commandBackend) Name() string {
+	return b.name
+}
+
+func (b *commandBackend) Available() error {
+	if len(b.args) == 0 {
+		return errors.New("no command configured (MINYLS_CLIPBOARD_COMMAND)")
+	}
+
+	if b.env != "" && os.Getenv(b.env) == "" {
+		return fmt.Errorf("$%s is not set", b.env)
+	}
+
+	_, err := exec.LookPath(b.args[0])
+	return err
+}
+
+func (b *commandBackend) Write(text string) error {
+	err := b.Available()
+	if err != nil {
+		return err
+	}
+
+	args := slices.Clone(b.args)
+
+	var stdin bool
+	if !slices.Contains(args, textPlaceholder) {
+		stdin = true
+	}
+
+	for i, arg := range args {
+		if arg == textPlaceholder {
+			args[i] = text
+		}
+	}
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec // the command is configured by the user
+	if stdin {
+		cmd.Stdin = strings.NewReader(text)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w (%s)", b.name, err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// windowsBackend uses the clipboard api of windows.
+type windowsBackend struct{}
+
+func (b *windowsBackend) Name() string {
+	return "windows"
+}
+
+func (b *windowsBackend) Available() error {
+	if !isWindows {
+		return errors.New("only available on windows")
+	}
+
+	return nil
+}
+
+func (b *windowsBackend) Write(text string) error {
+	err := b.Available()
+	if err != nil {
+		return err
+	}
+
+	return clipboard.WriteAll(text)
+}
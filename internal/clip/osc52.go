@@ -0,0 +1,83 @@
+package clip
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"golang.org/x/term"
+)
+
+var isWindows = runtime.GOOS == "windows"
+
+// osc52Backend writes the text to the clipboard of the terminal emulator
+// using the OSC 52 escape sequence. It also works over SSH and in tmux
+// (if 'set-clipboard' is enabled) as long as the terminal supports it.
+type osc52Backend struct{}
+
+func (b *osc52Backend) Name() string {
+	return "osc52"
+}
+
+func (b *osc52Backend) Available() error {
+	tty, err := openTTY()
+	if err != nil {
+		return err
+	}
+
+	return tty.Close()
+}
+
+func (b *osc52Backend) Write(text string) error {
+	tty, err := openTTY()
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+
+	// terminal multiplexers need the sequence to be passed through
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	case os.Getenv("STY") != "":
+		seq = "\x1bP" + seq + "\x1b\\"
+	}
+
+	_, err = io.WriteString(tty, seq)
+	if err != nil {
+		return fmt.Errorf("could not write to terminal: %w", err)
+	}
+
+	return tty.Close()
+}
+
+// openTTY opens the controlling terminal, falling back to
+// stderr if it is a terminal (e.g. on windows).
+func openTTY() (io.WriteCloser, error) {
+	if !isWindows {
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err == nil {
+			return tty, nil
+		}
+	}
+
+	fd := int(os.Stderr.Fd()) //nolint:gosec // file descriptors fit into int
+	if term.IsTerminal(fd) {
+		return nopCloser{os.Stderr}, nil
+	}
+
+	return nil, errors.New("no terminal available")
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
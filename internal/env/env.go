@@ -1,46 +1,202 @@
 package env
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
+
+	"github.com/devusSs/minyls/internal/paths"
 )
 
 type Env struct {
-	MinioEndpoint     string        `env:"MINIO_ENDPOINT"`
-	MinioAccessKey    string        `env:"MINIO_ACCESS_KEY"`
-	MinioAccessSecret string        `env:"MINIO_ACCESS_SECRET"`
-	MinioBucketName   string        `env:"MINIO_BUCKET_NAME"   envDefault:"minyls"`
-	MinioRegion       string        `env:"MINIO_REGION"        envDefault:"us-east-1"`
-	MinioLinkExpiry   time.Duration `env:"MINIO_LINK_EXPIRY"   envDefault:"168h"`
-	YOURLSEndpoint    string        `env:"YOURLS_ENDPOINT"`
-	YOURLSSignature   string        `env:"YOURLS_SIGNATURE"`
-	YOURLSTitle       string        `env:"YOURLS_TITLE"        envDefault:"shortened using minyls"`
+	MinioEndpoint      string        `env:"MINIO_ENDPOINT"`
+	MinioAccessKey     string        `env:"MINIO_ACCESS_KEY"`
+	MinioAccessSecret  string        `env:"MINIO_ACCESS_SECRET"`
+	MinioBucketName    string        `env:"MINIO_BUCKET_NAME"    envDefault:"minyls"`
+	MinioRegion        string        `env:"MINIO_REGION"         envDefault:"us-east-1"`
+	MinioLinkExpiry    time.Duration `env:"MINIO_LINK_EXPIRY"    envDefault:"168h"`
+	YOURLSEndpoint     string        `env:"YOURLS_ENDPOINT"      envDefault:""`
+	YOURLSSignature    string        `env:"YOURLS_SIGNATURE"     envDefault:""`
+	YOURLSTitle        string        `env:"YOURLS_TITLE"         envDefault:"shortened using minyls"`
+	GatewayURL         string        `env:"GATEWAY_URL"          envDefault:""`
+	GatewayAddress     string        `env:"GATEWAY_ADDRESS"      envDefault:":8080"`
+	GatewayUploadToken string        `env:"GATEWAY_UPLOAD_TOKEN" envDefault:""`
+	StorageBackend     string        `env:"STORAGE_BACKEND"      envDefault:"bolt"`
+	Clipboard          string        `env:"CLIPBOARD"            envDefault:"auto"`
+	ClipboardCommand   string        `env:"CLIPBOARD_COMMAND"    envDefault:""`
+	Format             string        `env:"FORMAT"               envDefault:"link"`
+
+	Log
+
+	// Profile is the name of the loaded profile, empty if none was used.
+	Profile string
+
+	// sources maps the env vars to the source of their value.
+	sources map[string]string
+}
+
+// Log contains the log settings, see log.Options.
+type Log struct {
+	LogLevel          string        `env:"LOG_LEVEL"             envDefault:"info"`
+	LogFormat         string        `env:"LOG_FORMAT"            envDefault:"json"`
+	LogDestination    string        `env:"LOG_DESTINATION"       envDefault:"file"`
+	LogSyslogAddress  string        `env:"LOG_SYSLOG_ADDRESS"    envDefault:""`
+	LogMaxAge         time.Duration `env:"LOG_MAX_AGE"           envDefault:"168h"`
+	LogMaxFiles       int           `env:"LOG_MAX_FILES"         envDefault:"100"`
+	LogMaxTotalSizeMB int64         `env:"LOG_MAX_TOTAL_SIZE_MB" envDefault:"100"`
+	LogCompress       bool          `env:"LOG_COMPRESS"          envDefault:"false"`
+	LogRolling        bool          `env:"LOG_ROLLING"           envDefault:"false"`
+	LogMaxFileSizeMB  int64         `env:"LOG_MAX_FILE_SIZE_MB"  envDefault:"10"`
+}
+
+// ShortenerEnabled reports whether links should be shortened using YOURLS.
+// Profiles without a YOURLS endpoint use the links directly.
+func (e *Env) ShortenerEnabled() bool {
+	return e.YOURLSEndpoint != ""
 }
 
-func Load() (*Env, error) {
-	exe, err := os.Executable()
+// Load loads the env using the specified profile of the config file
+// (see Config.selectProfile for the precedence if it is empty).
+// Env vars override the profile, values of the env file only act as defaults.
+func Load(profile string) (*Env, error) {
+	e, err := load(profile, true)
 	if err != nil {
-		return nil, fmt.Errorf("could not get executable: %w", err)
+		return nil, err
 	}
 
-	// ignore the error since we do not actually care
-	// if the env file was loaded or not, it simply eases
-	// up the process for the user
-	_ = godotenv.Load(filepath.Join(filepath.Dir(exe), ".minyls.env"))
+	return e, nil
+}
+
+// LoadPartial loads the env like Load, but missing required values are left
+// empty. If values cannot be parsed or resolved, the env is returned along with
+// the error, so Validate can still report all other problems.
+func LoadPartial(profile string) (*Env, error) {
+	return load(profile, false)
+}
+
+func load(profile string, required bool) (*Env, error) {
+	l, err := layer(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Env{Profile: l.profile, sources: l.sources}
+
+	secretsErr := resolveSecrets(l.environment, l.sources)
 
-	e := &Env{}
 	err = env.ParseWithOptions(e, env.Options{
-		RequiredIfNoDef: true,
-		Prefix:          "MINYLS_",
+		Environment:     l.environment,
+		RequiredIfNoDef: required,
+		Prefix:          envPrefix,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("could not parse env: %w", err)
+		err = fmt.Errorf("could not parse env: %w", err)
+	}
+
+	err = errors.Join(secretsErr, err)
+	if err != nil {
+		return e, err
 	}
 
 	return e, nil
 }
+
+// LoadLog loads only the log settings of the env. Unlike Load it
+// does not fail if other fields are missing, so it may be used
+// to set up the log before the env is loaded.
+func LoadLog(profile string) (*Log, error) {
+	l, err := layer(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	lc := &Log{}
+	err = env.ParseWithOptions(lc, env.Options{
+		Environment: l.environment,
+		Prefix:      envPrefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not parse log settings: %w", err)
+	}
+
+	return lc, nil
+}
+
+// layered is the environment combined from all sources.
+type layered struct {
+	profile     string
+	environment map[string]string
+	// sources maps the env vars to the source of their value.
+	sources map[string]string
+}
+
+// layer combines the env file, the profile of the config file and the env
+// vars, in order of precedence. The env file acts as the defaults below the
+// profile, so only real env vars override the selected profile.
+func layer(profile string) (*layered, error) {
+	envFile, err := paths.EnvFile()
+	if err != nil {
+		return nil, fmt.Errorf("could not get env file: %w", err)
+	}
+
+	// ignore the error since we do not actually care
+	// if the env file was loaded or not, it simply eases
+	// up the process for the user
+	fileEnv, _ := godotenv.Read(envFile)
+
+	configFile, err := paths.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("could not get config file: %w", err)
+	}
+
+	cfg, err := readConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	profile = cfg.selectProfile(profile, fileEnv)
+
+	profileEnv, err := cfg.profileEnvironment(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &layered{
+		profile:     profile,
+		environment: make(map[string]string),
+		sources:     make(map[string]string),
+	}
+
+	// later layers override the earlier ones
+	l.add(fileEnv, SourceEnvFile)
+	l.add(profileEnv, SourceProfile)
+	l.add(processEnv(), SourceEnv)
+
+	return l, nil
+}
+
+func (l *layered) add(values map[string]string, source string) {
+	for key, value := range values {
+		if !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+
+		l.environment[key] = value
+		l.sources[key] = source
+	}
+}
+
+func processEnv() map[string]string {
+	environment := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		environment[key] = value
+	}
+
+	return environment
+}
@@ -0,0 +1,116 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devusSs/minyls/internal/log"
+)
+
+// Sources of the values of the env.
+const (
+	SourceEnv     = "env"
+	SourceEnvFile = "env file"
+	SourceProfile = "profile"
+	SourceDefault = "default"
+	SourceUnset   = "unset"
+)
+
+// secretKeys are the env vars containing secrets,
+// they may contain secret references (see resolveSecret).
+var secretKeys = []string{
+	envPrefix + "MINIO_ACCESS_SECRET",
+	envPrefix + "YOURLS_SIGNATURE",
+	envPrefix + "GATEWAY_UPLOAD_TOKEN",
+}
+
+// Field describes a single field of the env.
+type Field struct {
+	// Key is the name of the env var, e.g. 'MINYLS_MINIO_ENDPOINT'.
+	Key string
+	// Name is the name in the config file, e.g. 'minio_endpoint'.
+	Name    string
+	Value   string
+	Default string
+	// HasDefault reports whether the field is optional.
+	HasDefault bool
+	Source     string
+	Secret     bool
+}
+
+// Fields returns all fields of e in the order of the struct.
+func (e *Env) Fields() []Field {
+	return e.fields(reflect.ValueOf(e).Elem())
+}
+
+func (e *Env) fields(v reflect.Value) []Field {
+	t := v.Type()
+
+	fields := make([]Field, 0, t.NumField())
+	for i := range t.NumField() {
+		if t.Field(i).Anonymous {
+			fields = append(fields, e.fields(v.Field(i))...)
+			continue
+		}
+
+		tag, ok := t.Field(i).Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		ownKey, _, _ := strings.Cut(tag, ",")
+		def, hasDef := t.Field(i).Tag.Lookup("envDefault")
+
+		f := Field{
+			Key:        envPrefix + ownKey,
+			Name:       strings.ToLower(ownKey),
+			Value:      fmt.Sprint(v.Field(i).Interface()),
+			Default:    def,
+			HasDefault: hasDef,
+		}
+
+		f.Secret = slices.Contains(secretKeys, f.Key)
+
+		f.Source = e.sources[f.Key]
+		if f.Source == "" {
+			f.Source = SourceUnset
+			if hasDef {
+				f.Source = SourceDefault
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// Secrets returns the values of all secret fields which are set.
+func (e *Env) Secrets() []string {
+	var values []string
+	for _, f := range e.Fields() {
+		if f.Secret && f.Value != "" {
+			values = append(values, f.Value)
+		}
+	}
+
+	return values
+}
+
+// MarshalZerologObject logs e with the secrets redacted.
+func (e *Env) MarshalZerologObject(ev *zerolog.Event) {
+	ev.Str("profile", e.Profile)
+
+	for _, f := range e.Fields() {
+		value := f.Value
+		if f.Secret && value != "" {
+			value = log.Redacted
+		}
+
+		ev.Str(f.Key, value)
+	}
+}
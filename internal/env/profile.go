@@ -0,0 +1,188 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/caarlos0/env/v11"
+)
+
+// Config is the config file (see paths.ConfigFile). It contains named
+// profiles whose fields are named like the env vars in lower case
+// without the 'MINYLS_' prefix, e.g.:
+//
+//	default_profile = "work"
+//
+//	[profiles.work]
+//	minio_endpoint = "https://minio.work.example"
+//	yourls_endpoint = "https://short.work.example/yourls-api.php"
+//
+//	[profiles.personal]
+//	minio_endpoint = "https://minio.example"
+type Config struct {
+	DefaultProfile string                    `toml:"default_profile"`
+	Profiles       map[string]map[string]any `toml:"profiles"`
+}
+
+const (
+	envPrefix  = "MINYLS_"
+	profileEnv = envPrefix + "PROFILE"
+)
+
+// readConfig reads the config file at path,
+// a missing config file results in an empty config.
+func readConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	_, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode config file: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// selectProfile returns the name of the profile to use, in order of precedence
+// the provided name, MINYLS_PROFILE (env or env file) or the default profile of the config.
+func (c *Config) selectProfile(name string, fileEnv map[string]string) string {
+	if name != "" {
+		return name
+	}
+
+	if name = os.Getenv(profileEnv); name != "" {
+		return name
+	}
+
+	if name = fileEnv[profileEnv]; name != "" {
+		return name
+	}
+
+	return c.DefaultProfile
+}
+
+// profileEnvironment returns the fields of the profile
+// as env vars, e.g. 'MINYLS_MINIO_ENDPOINT'.
+func (c *Config) profileEnvironment(name string) (map[string]string, error) {
+	environment := make(map[string]string)
+	if name == "" {
+		return environment, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' does not exist", name)
+	}
+
+	keys, err := envKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	for field, value := range profile {
+		key := envPrefix + strings.ToUpper(field)
+		if !slices.Contains(keys, key) {
+			return nil, fmt.Errorf("unknown field '%s' in profile '%s'", field, name)
+		}
+
+		environment[key] = fmt.Sprint(value)
+	}
+
+	return environment, nil
+}
+
+// envKeys returns the names of all env vars of Env.
+func envKeys() ([]string, error) {
+	params, err := env.GetFieldParamsWithOptions(&Env{}, env.Options{Prefix: envPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("could not get env fields: %w", err)
+	}
+
+	keys := make([]string, 0, len(params))
+	for _, p := range params {
+		keys = append(keys, p.Key)
+	}
+
+	return keys, nil
+}
+
+// WriteProfile stores the profile with the specified values (config file
+// field names, see Field.Name) in the config file at path. Other profiles
+// are kept, the profile becomes the default if there is none yet.
+func WriteProfile(path string, name string, values map[string]string) error {
+	cfg, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]map[string]any)
+	}
+
+	profile := make(map[string]any, len(values))
+	for key, value := range values {
+		profile[key] = value
+	}
+
+	cfg.Profiles[name] = profile
+	if cfg.DefaultProfile == "" {
+		cfg.DefaultProfile = name
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("could not create config dir: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open config file: %w", err)
+	}
+	defer f.Close()
+
+	// the file may have been created with other permissions before
+	err = f.Chmod(0600)
+	if err != nil {
+		return fmt.Errorf("could not set config file permissions: %w", err)
+	}
+
+	err = toml.NewEncoder(f).Encode(cfg)
+	if err != nil {
+		return fmt.Errorf("could not encode config file: %w", err)
+	}
+
+	return f.Close()
+}
+
+// ParseProfile parses the values of a profile (config file
+// field names, see Field.Name) without considering any env vars.
+func ParseProfile(values map[string]string) (*Env, error) {
+	environment := make(map[string]string, len(values))
+	for key, value := range values {
+		environment[envPrefix+strings.ToUpper(key)] = value
+	}
+
+	err := resolveSecrets(environment, make(map[string]string))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Env{}
+	err = env.ParseWithOptions(e, env.Options{
+		Environment:     environment,
+		RequiredIfNoDef: true,
+		Prefix:          envPrefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not parse profile: %w", err)
+	}
+
+	return e, nil
+}
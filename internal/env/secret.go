@@ -0,0 +1,111 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Prefixes of secret references. Instead of the plain secret the
+// secret fields may contain a reference which is resolved on Load:
+//
+//	file:/run/secrets/minio      the content of the file
+//	cmd:pass show minio/secret   the output of the command (split on spaces, no shell)
+//	env:OTHER_VAR                the value of another env var
+const (
+	secretRefFile = "file:"
+	secretRefCmd  = "cmd:"
+	secretRefEnv  = "env:"
+)
+
+const secretCmdTimeout = 30 * time.Second
+
+// resolveSecrets replaces the secret references in environment
+// with their values and marks their sources accordingly.
+func resolveSecrets(environment map[string]string, sources map[string]string) error {
+	for _, key := range secretKeys {
+		value, ok := environment[key]
+		if !ok {
+			continue
+		}
+
+		resolved, ref, err := resolveSecret(value, environment)
+		if err != nil {
+			return fmt.Errorf("could not resolve %s: %w", key, err)
+		}
+
+		if ref == "" {
+			continue
+		}
+
+		environment[key] = resolved
+		sources[key] += " (" + ref + ")"
+	}
+
+	return nil
+}
+
+// resolveSecret returns the value of the secret reference and the kind
+// of the reference. Values without a reference are returned as is.
+func resolveSecret(value string, environment map[string]string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(value, secretRefFile):
+		b, err := os.ReadFile(strings.TrimPrefix(value, secretRefFile))
+		if err != nil {
+			return "", "", fmt.Errorf("could not read secret file: %w", err)
+		}
+
+		return strings.TrimRight(string(b), "\r\n"), secretRefFile, nil
+	case strings.HasPrefix(value, secretRefCmd):
+		secret, err := runSecretCmd(strings.TrimPrefix(value, secretRefCmd))
+		return secret, secretRefCmd, err
+	case strings.HasPrefix(value, secretRefEnv):
+		name := strings.TrimPrefix(value, secretRefEnv)
+
+		secret, ok := environment[name]
+		if !ok {
+			secret, ok = os.LookupEnv(name)
+		}
+
+		if !ok {
+			return "", "", fmt.Errorf("env var '%s' is not set", name)
+		}
+
+		return secret, secretRefEnv, nil
+	default:
+		return value, "", nil
+	}
+}
+
+// runSecretCmd runs a credential helper and returns its output. The
+// command may prompt the user since stdin and stderr are passed through.
+func runSecretCmd(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", errors.New("empty secret command")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretCmdTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // the command is configured by the user
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("secret command '%s' failed: %w", args[0], err)
+	}
+
+	// credential helpers usually print the secret on the first line
+	secret, _, _ := strings.Cut(stdout.String(), "\n")
+	return strings.TrimRight(secret, "\r"), nil
+}
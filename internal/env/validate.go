@@ -0,0 +1,90 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Validate checks the values of e and returns all problems found. Values
+// depending on other packages (e.g. the clipboard backends) are checked by
+// their users.
+func (e *Env) Validate() error {
+	var errs []error
+
+	errs = append(errs, validateURL("MINYLS_MINIO_ENDPOINT", e.MinioEndpoint, true))
+
+	if e.MinioAccessKey == "" || e.MinioAccessSecret == "" {
+		errs = append(errs, errors.New("MINYLS_MINIO_ACCESS_KEY and MINYLS_MINIO_ACCESS_SECRET cannot be empty"))
+	}
+
+	if e.MinioBucketName == "" {
+		errs = append(errs, errors.New("MINYLS_MINIO_BUCKET_NAME cannot be empty"))
+	}
+
+	// negative expiries never expire, e.g. -1s
+	if e.MinioLinkExpiry == 0 {
+		errs = append(errs, errors.New("MINYLS_MINIO_LINK_EXPIRY cannot be 0, use e.g. -1s for links which never expire"))
+	}
+
+	if e.ShortenerEnabled() {
+		errs = append(errs, validateURL("MINYLS_YOURLS_ENDPOINT", e.YOURLSEndpoint, true))
+
+		if e.YOURLSSignature == "" {
+			errs = append(errs, errors.New("MINYLS_YOURLS_SIGNATURE cannot be empty if MINYLS_YOURLS_ENDPOINT is set"))
+		}
+	}
+
+	errs = append(errs, validateURL("MINYLS_GATEWAY_URL", e.GatewayURL, false))
+
+	if _, _, err := net.SplitHostPort(e.GatewayAddress); err != nil {
+		errs = append(errs, fmt.Errorf("MINYLS_GATEWAY_ADDRESS is invalid: %w", err))
+	}
+
+	if e.Clipboard == "command" && e.ClipboardCommand == "" {
+		errs = append(errs, errors.New("MINYLS_CLIPBOARD_COMMAND is required if MINYLS_CLIPBOARD is 'command'"))
+	}
+
+	if _, err := zerolog.ParseLevel(strings.ToLower(e.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("MINYLS_LOG_LEVEL is invalid: %w", err))
+	}
+
+	if e.LogMaxAge < 0 || e.LogMaxFiles < 0 || e.LogMaxTotalSizeMB < 0 || e.LogMaxFileSizeMB < 0 {
+		errs = append(errs, errors.New("MINYLS_LOG_* limits cannot be negative, use 0 to disable them"))
+	}
+
+	if e.LogRolling && e.LogMaxFileSizeMB == 0 {
+		errs = append(errs, errors.New("MINYLS_LOG_MAX_FILE_SIZE_MB needs to be set for MINYLS_LOG_ROLLING"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateURL(name string, value string, required bool) error {
+	if value == "" {
+		if required {
+			return fmt.Errorf("%s cannot be empty", name)
+		}
+
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid url: %w", name, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%s must use 'http://' or 'https://', got '%s'", name, value)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("%s is missing a host", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/minio"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// Server is the HTTP gateway (minyls serve). It maps the stable token
+// of a stored entry to its object, enforces the access rules of the entry
+// (expiry, revocation, password, download limit) and redirects
+// to a freshly presigned url.
+//
+// Since the presigned urls are created per request, entries served
+// via the gateway are not bound to the maximum presign expiry.
+type Server struct {
+	mc   *minio.Client
+	st   *storage.Store
+	addr string
+
+	uploadToken string
+	upload      UploadFunc
+}
+
+// Option configures optional features of the Server.
+type Option func(s *Server)
+
+// New creates a new Server listening on addr. The minio client
+// needs to be setup already.
+func New(mc *minio.Client, st *storage.Store, addr string, opts ...Option) *Server {
+	s := &Server{mc: mc, st: st, addr: addr}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+// ListenAndServe serves the gateway until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	go s.removeExhausted(ctx)
+
+	log.Log().Info().Str("func", "gateway.ListenAndServe").Str("addr", s.addr).Msg("serving gateway")
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		err := srv.Shutdown(shutdownCtx) //nolint:contextcheck // ctx is already done here
+		if err != nil {
+			return fmt.Errorf("could not shutdown server: %w", err)
+		}
+
+		return nil
+	case err := <-errs:
+		return fmt.Errorf("could not serve: %w", err)
+	}
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+sharePath+"{token}", s.handleShare)
+	// GET patterns also match HEAD, link previews must not count as downloads
+	mux.HandleFunc("HEAD "+sharePath+"{token}", s.handleShareHead)
+	mux.HandleFunc("POST "+sharePath+"{token}", s.handleShare)
+
+	if s.upload != nil {
+		mux.HandleFunc("POST "+UploadPath, s.handleUpload)
+	}
+
+	return mux
+}
+
+// presignExpiry is the expiry of the presigned urls the gateway
+// redirects to. They only need to be valid for the redirect itself.
+// Entries with a download limit use limitedPresignExpiry.
+const presignExpiry = 5 * time.Minute
+
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.findShare(w, r)
+	if !ok {
+		return
+	}
+
+	if entry.Protected && !s.checkPassword(w, r, entry) {
+		return
+	}
+
+	err := s.countDownload(entry)
+	if errors.Is(err, errDownloadLimitReached) {
+		http.Error(w, "download limit reached", http.StatusGone)
+		return
+	}
+	if err != nil {
+		s.internalError(w, "could not count download", err)
+		return
+	}
+
+	link, err := s.mc.Link(r.Context(), entry.Bucket, entry.Object, entryPresignExpiry(entry))
+	if err != nil {
+		s.internalError(w, "could not create link", err)
+		return
+	}
+
+	log.Log().Info().Str("func", "gateway.handleShare").Int("id", entry.ID).Msg("redirecting to object")
+
+	http.Redirect(w, r, link, http.StatusSeeOther)
+}
+
+// handleShareHead reports whether the link is still valid
+// without counting a download or revealing the object url.
+func (s *Server) handleShareHead(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.findShare(w, r)
+	if !ok {
+		return
+	}
+
+	if entry.Exhausted() {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// findShare returns the entry of the requested token. If it does not
+// exist or is no longer valid, an error is written and false returned.
+func (s *Server) findShare(w http.ResponseWriter, r *http.Request) (*storage.DataEntry, bool) {
+	entry, err := s.st.FindByToken(r.PathValue("token"))
+	if errors.Is(err, storage.ErrEntryNotFound) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		s.internalError(w, "could not find entry", err)
+		return nil, false
+	}
+
+	if entry.Deleted || entry.Revoked || entry.Expired(time.Now()) {
+		http.Error(w, "link expired", http.StatusGone)
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (s *Server) internalError(w http.ResponseWriter, msg string, err error) {
+	log.Log().Err(err).Str("func", "gateway.internalError").Msg(msg)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+var errDownloadLimitReached = errors.New("download limit reached")
+
+// countDownload counts a download for entries with a download limit.
+// It returns errDownloadLimitReached if no downloads are left.
+func (s *Server) countDownload(entry *storage.DataEntry) error {
+	if entry.MaxDownloads == 0 {
+		return nil
+	}
+
+	_, err := s.st.UpdateEntry(entry.ID, func(e *storage.DataEntry) error {
+		if e.Deleted || e.Exhausted() {
+			return errDownloadLimitReached
+		}
+
+		e.Downloads++
+		e.LastDownload = time.Now()
+		return nil
+	})
+
+	return err
+}
+
+// limitedPresignExpiry is the expiry of the presigned urls of entries with
+// a download limit. Until it passes, the url of the last download may be
+// used again, the object is removed at most removeExhaustedInterval later.
+const (
+	limitedPresignExpiry    = 30 * time.Second
+	removeExhaustedInterval = 15 * time.Second
+)
+
+// entryPresignExpiry returns the expiry of the presigned urls of entry.
+func entryPresignExpiry(entry *storage.DataEntry) time.Duration {
+	if entry.MaxDownloads > 0 {
+		return limitedPresignExpiry
+	}
+
+	return presignExpiry
+}
+
+// removeExhausted periodically deletes the objects of entries
+// which reached their download limit until ctx is done.
+func (s *Server) removeExhausted(ctx context.Context) {
+	ticker := time.NewTicker(removeExhaustedInterval)
+	defer ticker.Stop()
+
+	for {
+		s.removeExhaustedOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) removeExhaustedOnce(ctx context.Context) {
+	data, err := s.st.Read()
+	if err != nil {
+		log.Log().Err(err).Str("func", "gateway.removeExhaustedOnce").Msg("could not read storage")
+		return
+	}
+
+	for _, entry := range data.Entries {
+		// the object may only be deleted once the presigned url
+		// of the last download expired, else the download would fail
+		if entry.Deleted || !entry.Exhausted() || time.Since(entry.LastDownload) <= entryPresignExpiry(entry) {
+			continue
+		}
+
+		err = s.mc.Remove(ctx, entry.Bucket, entry.Object)
+		if err != nil {
+			log.Log().
+				Err(err).
+				Str("func", "gateway.removeExhaustedOnce").
+				Int("id", entry.ID).
+				Msg("could not remove object")
+			continue
+		}
+
+		_, err = s.st.UpdateEntry(entry.ID, func(e *storage.DataEntry) error {
+			e.Deleted = true
+			return nil
+		})
+		if err != nil {
+			log.Log().
+				Err(err).
+				Str("func", "gateway.removeExhaustedOnce").
+				Int("id", entry.ID).
+				Msg("could not update entry")
+			continue
+		}
+
+		log.Log().
+			Info().
+			Str("func", "gateway.removeExhaustedOnce").
+			Int("id", entry.ID).
+			Msg("removed exhausted object")
+	}
+}
@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// PasswordMetadataKey is the object metadata key the
+// password hash of protected entries is stored under.
+const PasswordMetadataKey = "Minyls-Password-Hash"
+
+// HashPassword returns the hash of password which
+// should be stored on the object using PasswordMetadataKey.
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", errors.New("password cannot be empty")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("could not hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// checkPassword renders the password form until the request
+// contains the correct password for the entry. It reports whether
+// the request may access the object.
+func (s *Server) checkPassword(w http.ResponseWriter, r *http.Request, entry *storage.DataEntry) bool {
+	if r.Method != http.MethodPost {
+		renderPasswordForm(w, http.StatusOK, "")
+		return false
+	}
+
+	hash, err := s.mc.Metadata(r.Context(), entry.Bucket, entry.Object, PasswordMetadataKey)
+	if err != nil {
+		s.internalError(w, "could not get password hash", err)
+		return false
+	}
+
+	// a protected entry without a hash would otherwise
+	// be accessible without any password
+	if hash == "" {
+		s.internalError(w, "missing password hash", fmt.Errorf("entry %d has no password hash", entry.ID))
+		return false
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(r.PostFormValue("password")))
+	if err != nil {
+		log.Log().Warn().Str("func", "gateway.checkPassword").Int("id", entry.ID).Msg("wrong password")
+		renderPasswordForm(w, http.StatusUnauthorized, "Wrong password.")
+		return false
+	}
+
+	return true
+}
+
+var passwordForm = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>minyls - password required</title>
+</head>
+<body>
+  <form method="post">
+    <p>This file is password protected.</p>
+    {{if .}}<p><strong>{{.}}</strong></p>{{end}}
+    <input type="password" name="password" autofocus required>
+    <button type="submit">Download</button>
+  </form>
+</body>
+</html>
+`))
+
+func renderPasswordForm(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	err := passwordForm.Execute(w, msg)
+	if err != nil {
+		log.Log().Err(err).Str("func", "gateway.renderPasswordForm").Msg("could not render form")
+	}
+}
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/url"
+)
+
+const (
+	sharePath   = "/s/"
+	tokenLength = 16
+)
+
+// NewToken creates a new random token used to identify
+// an entry on the gateway.
+func NewToken() string {
+	b := make([]byte, tokenLength)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Link returns the share link for token on the gateway
+// reachable at baseURL.
+func Link(baseURL string, token string) (string, error) {
+	if baseURL == "" {
+		return "", errors.New("gateway url cannot be empty")
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+
+	return u.JoinPath(sharePath, token).String(), nil
+}
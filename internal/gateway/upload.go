@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devusSs/minyls/internal/log"
+	"github.com/devusSs/minyls/internal/storage"
+)
+
+// UploadPath is the path of the upload endpoint. It accepts multipart
+// form data with the file in the UploadFormField field and an optional
+// 'policy' query parameter ('public' or 'private', defaults to 'private').
+const (
+	UploadPath      = "/api/upload"
+	UploadFormField = "file"
+)
+
+// UploadFunc uploads the file at filePath with its original name
+// and returns the stored entry.
+type UploadFunc func(ctx context.Context, filePath string, name string, public bool) (*storage.DataEntry, error)
+
+// WithUpload enables the upload endpoint (e.g. for ShareX).
+// Requests need to send token as bearer token.
+func WithUpload(token string, fn UploadFunc) Option {
+	return func(s *Server) {
+		if token == "" || fn == nil {
+			return
+		}
+
+		s.uploadToken = token
+		s.upload = fn
+	}
+}
+
+// maxUploadSize limits the size of files uploaded via the upload endpoint.
+const maxUploadSize = 1 << 30
+
+type uploadResponse struct {
+	ID    int    `json:"id,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeUploadResponse(w, http.StatusUnauthorized, &uploadResponse{Error: "unauthorized"})
+		return
+	}
+
+	policy := r.URL.Query().Get("policy")
+	if policy != "" && policy != "public" && policy != "private" {
+		writeUploadResponse(w, http.StatusBadRequest, &uploadResponse{Error: "invalid policy"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	fp, name, err := saveUploadedFile(r)
+	if err != nil {
+		log.Log().Err(err).Str("func", "gateway.handleUpload").Msg("could not save uploaded file")
+		writeUploadResponse(w, http.StatusBadRequest, &uploadResponse{Error: "invalid upload"})
+		return
+	}
+	defer os.Remove(fp)
+
+	entry, err := s.upload(r.Context(), fp, name, policy == "public")
+	if err != nil {
+		log.Log().Err(err).Str("func", "gateway.handleUpload").Msg("upload failed")
+		writeUploadResponse(w, http.StatusInternalServerError, &uploadResponse{Error: "upload failed"})
+		return
+	}
+
+	log.Log().Info().Str("func", "gateway.handleUpload").Int("id", entry.ID).Msg("uploaded file")
+
+	writeUploadResponse(w, http.StatusOK, &uploadResponse{ID: entry.ID, URL: entry.YOURLSLink})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.uploadToken)) == 1
+}
+
+// saveUploadedFile streams the uploaded file into a temporary file
+// which keeps the extension of the original name. It returns
+// the path of the temporary file and the original name.
+func saveUploadedFile(r *http.Request) (string, string, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return "", "", fmt.Errorf("could not read multipart form: %w", err)
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return "", "", fmt.Errorf("missing form field '%s'", UploadFormField)
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("could not read part: %w", err)
+		}
+
+		if part.FormName() != UploadFormField {
+			continue
+		}
+
+		name := filepath.Base(part.FileName())
+		fp, err := writeTempFile(part, filepath.Ext(name))
+		if err != nil {
+			return "", "", err
+		}
+
+		return fp, name, nil
+	}
+}
+
+func writeTempFile(r io.Reader, ext string) (string, error) {
+	f, err := os.CreateTemp("", "minyls-upload-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("could not create temp file: %w", err)
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("could not write temp file: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("could not close temp file: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+func writeUploadResponse(w http.ResponseWriter, status int, res *uploadResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(res)
+	if err != nil {
+		log.Log().Err(err).Str("func", "gateway.writeUploadResponse").Msg("could not write response")
+	}
+}
@@ -9,39 +9,118 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+
+	"github.com/devusSs/minyls/internal/paths"
 )
 
+// Options configure the logging. Zero values of the
+// limits disable the respective limit.
+type Options struct {
+	// Level is the minimum level, see zerolog.ParseLevel.
+	Level string
+	// Format is one of Formats.
+	Format string
+	// Destination is one of Destinations.
+	Destination string
+	// SyslogAddress is the path of the syslog socket,
+	// the usual local sockets are tried if it is empty.
+	SyslogAddress string
+
+	// MaxAge removes log files older than this.
+	MaxAge time.Duration
+	// MaxFiles removes the oldest log files exceeding this count.
+	MaxFiles int
+	// MaxTotalSize removes the oldest log files exceeding this size in bytes.
+	MaxTotalSize int64
+	// Compress gzips old log files.
+	Compress bool
+	// Rolling writes to a single log file instead of one per process,
+	// it is rotated once it exceeds MaxFileSize bytes.
+	Rolling     bool
+	MaxFileSize int64
+}
+
 // Setup creates needed directories, log file(s), sets the level
 // and removes old log files if needed.
-func Setup() error {
+//
+// MINYLS_DEVELOPMENT=true is a shortcut for debug logs to stderr in the console format.
+func Setup(opts Options) error {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix //nolint:reassign // dont annoy me
-	debug := strings.ToLower(os.Getenv("MINYLS_DEVELOPMENT")) == "true"
 
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	debug := strings.ToLower(os.Getenv("MINYLS_DEVELOPMENT")) == "true"
 	if debug {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-		newLogger := log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-		logger = &newLogger
-		logger.Warn().Msg("debug logging enabled")
-		return nil
+		opts.Level = zerolog.LevelDebugValue
+		opts.Format = FormatConsole
+		opts.Destination = DestinationStderr
 	}
 
-	err := createLogsDirIfNotExists()
-	if err != nil {
-		return fmt.Errorf("createLogsDirIfNotExists: %w", err)
+	level := zerolog.InfoLevel
+	if opts.Level != "" {
+		var err error
+		level, err = zerolog.ParseLevel(strings.ToLower(opts.Level))
+		if err != nil {
+			return fmt.Errorf("invalid log level: %w", err)
+		}
 	}
 
-	go removeOldLogs()
+	zerolog.SetGlobalLevel(level)
 
-	w, err := createLogFile()
-	if err != nil {
-		return fmt.Errorf("createLogFile: %w", err)
+	out := &output{format: opts.Format}
+
+	var active string
+	switch opts.Destination {
+	case DestinationFile, DestinationBoth, "":
+		err := createLogsDirIfNotExists()
+		if err != nil {
+			return fmt.Errorf("createLogsDirIfNotExists: %w", err)
+		}
+
+		var w io.Writer
+		w, active, err = createLogFile(opts)
+		if err != nil {
+			return fmt.Errorf("createLogFile: %w", err)
+		}
+
+		out.sinks = append(out.sinks, sink{w: w})
+		if opts.Destination == DestinationBoth {
+			out.sinks = append(out.sinks, stderrSink())
+		}
+	case DestinationStderr:
+		out.sinks = append(out.sinks, stderrSink())
+	case DestinationSyslog:
+		w, err := newSyslogWriter(opts.SyslogAddress)
+		if err != nil {
+			return err
+		}
+
+		out.sinks = append(out.sinks, sink{w: w})
+	default:
+		return fmt.Errorf("unknown log destination '%s'", opts.Destination)
 	}
 
-	newLogger := zerolog.New(w)
+	newLogger := zerolog.New(out).With().Timestamp().Logger()
 	logger = &newLogger
 
+	if debug {
+		logger.Warn().Msg("debug logging enabled")
+	}
+
+	logger.Debug().
+		Str("func", "log.Setup").
+		Str("log_level", level.String()).
+		Str("format", opts.Format).
+		Str("destination", opts.Destination).
+		Msg("setup log")
+
+	if active == "" {
+		return nil
+	}
+
+	err := cleanupLogs(opts, active)
+	if err != nil {
+		logger.Warn().Err(err).Str("func", "log.Setup").Msg("could not clean up old logs")
+	}
+
 	return nil
 }
 
@@ -52,18 +131,21 @@ func Log() *zerolog.Logger {
 	return logger
 }
 
-var logger *zerolog.Logger
+// logger writes to stderr until Setup has been called.
+var logger = func() *zerolog.Logger {
+	l := zerolog.New(&output{format: FormatConsole, sinks: []sink{stderrSink()}}).With().Timestamp().Logger()
+	return &l
+}()
 
 var logsDir = ""
 
 func createLogsDirIfNotExists() error {
-	exe, err := os.Executable()
+	var err error
+	logsDir, err = paths.LogsDir()
 	if err != nil {
-		return fmt.Errorf("could not find executable: %w", err)
+		return fmt.Errorf("could not find logs directory: %w", err)
 	}
 
-	logsDir = filepath.Join(filepath.Dir(exe), "logs")
-
 	err = os.MkdirAll(logsDir, 0700)
 	if err != nil {
 		return fmt.Errorf("could not create logs directory: %w", err)
@@ -72,49 +154,58 @@ func createLogsDirIfNotExists() error {
 	return nil
 }
 
-var logFileName = "minyls_" + time.Now().Format("2006-01-02_15-04-05") + ".log.json"
+const (
+	logFilePrefix = "minyls"
+	logFileExt    = ".log"
+	timeLayout    = "2006-01-02_15-04-05"
+)
 
-func createLogFile() (io.Writer, error) {
-	f, err := os.Create(filepath.Join(logsDir, logFileName))
-	if err != nil {
-		return nil, fmt.Errorf("could not create log file: %w", err)
+// logFileSuffix returns the suffix of log files in format,
+// e.g. '.log.json'. Console logs are plain '.log' files.
+func logFileSuffix(format string) string {
+	switch format {
+	case FormatConsole:
+		return logFileExt
+	case FormatJSON, "":
+		return logFileExt + "." + FormatJSON
+	default:
+		return logFileExt + "." + format
 	}
-
-	return f, nil
 }
 
-const logFileMaxAge = 7 * 24 * time.Hour
-
-func removeOldLogs() {
-	files, err := os.ReadDir(logsDir)
-	if err != nil {
-		fmt.Println("log.removeOldLogs: could not read logsDir:", err)
-		return
-	}
-
-	for _, file := range files {
-		fp := filepath.Join(logsDir, file.Name())
+// rolledLogFileName returns the name of a log file in format created at t.
+func rolledLogFileName(t time.Time, format string) string {
+	return logFilePrefix + "_" + t.Format(timeLayout) + logFileSuffix(format)
+}
 
-		if file.IsDir() {
-			fmt.Println("log.removeOldLogs: unexpected dir:", fp)
-			continue
-		}
+// createLogFile opens the log file and returns it with its name. Processes
+// started within the same second share the file, so it is opened for appending.
+// The file stays locked until the process exits, see openLogFile.
+func createLogFile(opts Options) (io.Writer, string, error) {
+	if opts.Rolling {
+		name := logFilePrefix + logFileSuffix(opts.Format)
 
-		var info os.FileInfo
-		info, err = file.Info()
+		w, err := newRollingWriter(filepath.Join(logsDir, name), opts.MaxFileSize, opts.Format)
 		if err != nil {
-			fmt.Println("log.removeOldLogs: could not read file info:", err)
-			continue
+			return nil, "", fmt.Errorf("could not open rolling log file: %w", err)
 		}
 
-		if time.Since(info.ModTime()) <= logFileMaxAge {
-			continue
-		}
+		return w, name, nil
+	}
 
-		err = os.Remove(fp)
-		if err != nil {
-			fmt.Println("could not remove file:", err)
-			continue
-		}
+	name := rolledLogFileName(time.Now(), opts.Format)
+
+	f, unlock, err := openLogFile(filepath.Join(logsDir, name))
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create log file: %w", err)
 	}
+
+	return &lockedFile{File: f, unlock: unlock}, name, nil
+}
+
+// lockedFile references the lock of the log file, so it is
+// not released by the garbage collector while it is written to.
+type lockedFile struct {
+	*os.File
+	unlock func()
 }
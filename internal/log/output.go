@@ -0,0 +1,192 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/term"
+)
+
+// Available log formats.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+	FormatLogfmt  = "logfmt"
+)
+
+// Available log destinations.
+const (
+	DestinationFile   = "file"
+	DestinationStderr = "stderr"
+	DestinationBoth   = "both"
+	DestinationSyslog = "syslog"
+)
+
+// Formats and Destinations list the valid values for validation.
+var (
+	Formats      = []string{FormatJSON, FormatConsole, FormatLogfmt}
+	Destinations = []string{DestinationFile, DestinationStderr, DestinationBoth, DestinationSyslog}
+)
+
+// sink is a single destination of the log lines.
+type sink struct {
+	w io.Writer
+	// color enables colors for the console format.
+	color bool
+}
+
+// levelWriter is implemented by sinks which need the level, e.g. syslog.
+type levelWriter interface {
+	WriteLevel(level zerolog.Level, p []byte) (int, error)
+}
+
+// output redacts the log lines and writes them to
+// all sinks using the configured format.
+type output struct {
+	mu     sync.Mutex
+	format string
+	sinks  []sink
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	return o.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (o *output) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	line := redact(p)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var errs []error
+	for _, s := range o.sinks {
+		formatted, err := formatLine(o.format, line, s.color)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if lw, ok := s.w.(levelWriter); ok {
+			_, err = lw.WriteLevel(level, formatted)
+		} else {
+			_, err = s.w.Write(formatted)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// stderrSink writes to stderr with colors if it is a terminal.
+func stderrSink() sink {
+	fd := int(os.Stderr.Fd()) //nolint:gosec // file descriptors fit into int
+	return sink{w: os.Stderr, color: term.IsTerminal(fd)}
+}
+
+// formatLine converts the json log line p into format.
+func formatLine(format string, p []byte, color bool) ([]byte, error) {
+	switch format {
+	case FormatJSON, "":
+		return p, nil
+	case FormatConsole:
+		var buf bytes.Buffer
+
+		cw := zerolog.ConsoleWriter{Out: &buf, NoColor: !color, TimeFormat: time.DateTime}
+		_, err := cw.Write(p)
+		return buf.Bytes(), err
+	case FormatLogfmt:
+		return logfmt(p)
+	default:
+		return nil, fmt.Errorf("unknown log format '%s'", format)
+	}
+}
+
+// logfmtFirst are written first in this order, all other keys are sorted.
+var logfmtFirst = []string{
+	zerolog.TimestampFieldName,
+	zerolog.LevelFieldName,
+	zerolog.MessageFieldName,
+}
+
+// logfmt converts the json log line p into the logfmt format (key=value).
+func logfmt(p []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+
+	var fields map[string]any
+	err := d.Decode(&fields)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode log line: %w", err)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if !slices.Contains(logfmtFirst, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	for _, key := range slices.Concat(logfmtFirst, keys) {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(value))
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+func logfmtValue(value any) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return strconv.Quote(fmt.Sprint(v))
+		}
+
+		s = string(b)
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\n\\") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
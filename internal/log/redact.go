@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sync"
+)
+
+// Redacted replaces secrets in the logs.
+const Redacted = "[redacted]"
+
+// minSecretLength prevents masking very short values, which would
+// garble unrelated parts of the logs.
+const minSecretLength = 4
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecrets registers values which are masked wherever they appear in the logs.
+func AddSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, value := range values {
+		if len(value) < minSecretLength {
+			continue
+		}
+
+		secrets = append(secrets, value)
+
+		// the json encoder escapes some characters
+		b, err := json.Marshal(value)
+		if err == nil && string(b[1:len(b)-1]) != value {
+			secrets = append(secrets, string(b[1:len(b)-1]))
+		}
+	}
+}
+
+// presignParams matches the signature and security token of presigned urls.
+var presignParams = regexp.MustCompile(`(?i)(X-Amz-Signature|X-Amz-Security-Token)=[^&"\s\\]+`)
+
+// redact masks the registered secrets and presign signatures in p.
+func redact(p []byte) []byte {
+	p = presignParams.ReplaceAll(p, []byte("${1}="+Redacted))
+
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range secrets {
+		p = bytes.ReplaceAll(p, []byte(secret), []byte(Redacted))
+	}
+
+	return p
+}
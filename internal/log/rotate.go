@@ -0,0 +1,336 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// rollingWriter writes to a single log file and rotates it
+// into a timestamped log file once it exceeds maxSize bytes.
+type rollingWriter struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	format  string
+	f       *os.File
+	unlock  func()
+	size    int64
+}
+
+func newRollingWriter(path string, maxSize int64, format string) (*rollingWriter, error) {
+	w := &rollingWriter{path: path, maxSize: maxSize, format: format}
+
+	err := w.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *rollingWriter) open() error {
+	if info, err := os.Stat(w.path); err == nil && w.maxSize > 0 && info.Size() >= w.maxSize {
+		w.rotate()
+	}
+
+	f, unlock, err := openLogFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		unlock()
+		f.Close()
+		return err
+	}
+
+	w.f = f
+	w.unlock = unlock
+	w.size = info.Size()
+
+	return nil
+}
+
+// rotate moves the rolling log file aside. Other processes may have
+// rotated it already, so errors are ignored.
+func (w *rollingWriter) rotate() {
+	rotated := filepath.Join(filepath.Dir(w.path), rolledLogFileName(time.Now(), w.format))
+	if _, err := os.Stat(rotated); err == nil {
+		return
+	}
+
+	_ = os.Rename(w.path, rotated)
+}
+
+func (w *rollingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size+int64(len(p)) > w.maxSize && w.size > 0 {
+		w.unlock()
+		w.f.Close()
+
+		err := w.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// compressDelay keeps log files which have just been
+// written to by other processes from being compressed.
+const compressDelay = time.Minute
+
+// openLogFile opens the log file at path for appending and takes a shared
+// lock on it, which is held until unlock is called or the process exits.
+// cleanupLogs skips locked files, so the log files of running processes
+// (e.g. 'minyls serve') are neither compressed nor removed. The file is
+// opened again if a cleanup removed it while waiting for the lock.
+func openLogFile(path string) (*os.File, func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// open files cannot be removed on windows and locks would block writes
+		if runtime.GOOS == "windows" {
+			return f, func() {}, nil
+		}
+
+		fl := flock.New(path)
+
+		err = fl.RLock()
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("could not lock log file: %w", err)
+		}
+
+		if isFile(f, path) {
+			return f, func() { _ = fl.Unlock() }, nil
+		}
+
+		_ = fl.Unlock()
+		f.Close()
+	}
+}
+
+// isFile reports whether path still refers to f.
+func isFile(f *os.File, path string) bool {
+	a, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	b, err := os.Stat(path)
+
+	return err == nil && os.SameFile(a, b)
+}
+
+// tryLockLogFile takes an exclusive lock on the log file unless a process
+// is still writing to it, see openLogFile. The returned func releases the
+// lock, ok is false if the file is in use. Compressed files are not written
+// to, so they are not locked.
+func tryLockLogFile(name string) (func(), bool) {
+	if strings.HasSuffix(name, ".gz") || runtime.GOOS == "windows" {
+		return func() {}, true
+	}
+
+	// the file must not be created if it has been removed in the meantime
+	fl := flock.New(filepath.Join(logsDir, name), flock.SetFlag(os.O_RDONLY))
+
+	ok, err := fl.TryLock()
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	return func() { _ = fl.Unlock() }, true
+}
+
+type logFile struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+// cleanupLogs compresses old log files if enabled and removes the ones
+// exceeding the limits of opts, starting with the oldest.
+// The active log file and files locked by other processes are never touched.
+func cleanupLogs(opts Options, active string) error {
+	files, err := readLogFiles()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	if opts.Compress {
+		for i, f := range files {
+			if f.name == active || strings.HasSuffix(f.name, ".gz") || time.Since(f.modTime) < compressDelay {
+				continue
+			}
+
+			unlock, ok := tryLockLogFile(f.name)
+			if !ok {
+				continue
+			}
+
+			var compressed *logFile
+			compressed, err = compressLogFile(f)
+			unlock()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			files[i] = compressed
+		}
+	}
+
+	// newest first
+	slices.SortFunc(files, func(a, b *logFile) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	var (
+		count int
+		total int64
+	)
+
+	for _, f := range files {
+		count++
+		total += f.size
+
+		remove := (opts.MaxAge > 0 && time.Since(f.modTime) > opts.MaxAge) ||
+			(opts.MaxFiles > 0 && count > opts.MaxFiles) ||
+			(opts.MaxTotalSize > 0 && total > opts.MaxTotalSize)
+
+		if !remove || f.name == active {
+			continue
+		}
+
+		unlock, ok := tryLockLogFile(f.name)
+		if !ok {
+			continue
+		}
+
+		count--
+		total -= f.size
+
+		err = os.Remove(filepath.Join(logsDir, f.name))
+		unlock()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("could not remove log file: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func readLogFiles() ([]*logFile, error) {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read logs dir: %w", err)
+	}
+
+	files := make([]*logFile, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, logFilePrefix) || !strings.Contains(name, logFileExt) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// removed by another process in the meantime
+			continue
+		}
+
+		files = append(files, &logFile{name: name, modTime: info.ModTime(), size: info.Size()})
+	}
+
+	return files, nil
+}
+
+// compressLogFile gzips the log file and removes the original,
+// the compressed file keeps the modification time of the original.
+func compressLogFile(f *logFile) (*logFile, error) {
+	src := filepath.Join(logsDir, f.name)
+	dst := src + ".gz"
+	tmp := fmt.Sprintf("%s.tmp-%d", dst, os.Getpid())
+
+	size, err := gzipFile(src, tmp)
+	if err != nil {
+		os.Remove(tmp)
+
+		// compressed by another process in the meantime
+		if errors.Is(err, os.ErrNotExist) {
+			return &logFile{name: f.name + ".gz", modTime: f.modTime}, nil
+		}
+
+		return nil, fmt.Errorf("could not compress log file: %w", err)
+	}
+
+	err = os.Chtimes(tmp, f.modTime, f.modTime)
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("could not compress log file: %w", err)
+	}
+
+	_ = os.Remove(src)
+
+	return &logFile{name: f.name + ".gz", modTime: f.modTime, size: size}, nil
+}
+
+func gzipFile(src string, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	gw := gzip.NewWriter(out)
+
+	_, err = io.Copy(gw, in)
+	if err != nil {
+		return 0, err
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), out.Close()
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// syslogSockets are the usual local syslog sockets.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogWriter writes to a local syslog socket (RFC 3164 format).
+type syslogWriter struct {
+	conn     net.Conn
+	hostname string
+}
+
+// newSyslogWriter connects to the syslog socket at address
+// or to the first usual local socket if it is empty.
+func newSyslogWriter(address string) (*syslogWriter, error) {
+	addresses := syslogSockets
+	if address != "" {
+		addresses = []string{address}
+	}
+
+	var errs []error
+	for _, a := range addresses {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, a)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			hostname, _ := os.Hostname()
+			return &syslogWriter{conn: conn, hostname: hostname}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not connect to syslog: %w", errors.Join(errs...))
+}
+
+// syslog facility user (1) and severities.
+const (
+	syslogFacilityUser = 1 << 3
+
+	syslogCrit    = 2
+	syslogErr     = 3
+	syslogWarning = 4
+	syslogInfo    = 6
+	syslogDebug   = 7
+)
+
+func syslogSeverity(level zerolog.Level) int {
+	switch level {
+	case zerolog.PanicLevel, zerolog.FatalLevel:
+		return syslogCrit
+	case zerolog.ErrorLevel:
+		return syslogErr
+	case zerolog.WarnLevel:
+		return syslogWarning
+	case zerolog.DebugLevel, zerolog.TraceLevel:
+		return syslogDebug
+	default:
+		return syslogInfo
+	}
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *syslogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	_, err := fmt.Fprintf(
+		w.conn,
+		"<%d>%s %s minyls[%d]: %s",
+		syslogFacilityUser|syslogSeverity(level),
+		time.Now().Format(time.Stamp),
+		w.hostname,
+		os.Getpid(),
+		p,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
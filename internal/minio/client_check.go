@@ -0,0 +1,131 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// BucketPolicy returns the policy of the specified bucket
+// or an empty string if it has none.
+func (c *Client) BucketPolicy(ctx context.Context, bucketName string) (string, error) {
+	policy, err := c.client.GetBucketPolicy(ctx, bucketName)
+	if err != nil {
+		return "", fmt.Errorf("could not get bucket policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// AllowsPublicRead reports whether policy allows anyone to get objects.
+func AllowsPublicRead(policy string) bool {
+	var p struct {
+		Statement []struct {
+			Effect    string          `json:"Effect"`
+			Principal json.RawMessage `json:"Principal"`
+			Action    json.RawMessage `json:"Action"`
+		} `json:"Statement"`
+	}
+
+	err := json.Unmarshal([]byte(policy), &p)
+	if err != nil {
+		return false
+	}
+
+	for _, s := range p.Statement {
+		if s.Effect != "Allow" || !strings.Contains(string(s.Principal), "*") {
+			continue
+		}
+
+		if slices.Contains(stringOrSlice(s.Action), "s3:GetObject") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stringOrSlice decodes a policy value which may either be a string or a list.
+func stringOrSlice(raw json.RawMessage) []string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return []string{s}
+	}
+
+	var l []string
+	_ = json.Unmarshal(raw, &l)
+	return l
+}
+
+const probeExpiry = time.Minute
+
+// Probe puts a small object into the specified bucket, fetches it
+// using a presigned url and deletes it again.
+func (c *Client) Probe(ctx context.Context, bucketName string) error {
+	key := "minyls-probe-" + uuid.NewString()
+	content := []byte("minyls probe " + time.Now().String())
+
+	_, err := c.client.PutObject(
+		ctx,
+		bucketName,
+		key,
+		bytes.NewReader(content),
+		int64(len(content)),
+		minio.PutObjectOptions{ContentType: "text/plain"},
+	)
+	if err != nil {
+		return fmt.Errorf("could not put probe object: %w", err)
+	}
+
+	fetchErr := c.fetchProbe(ctx, bucketName, key, content)
+
+	err = c.Remove(ctx, bucketName, key)
+	if err != nil {
+		return fmt.Errorf("could not delete probe object: %w", err)
+	}
+
+	return fetchErr
+}
+
+func (c *Client) fetchProbe(ctx context.Context, bucketName string, key string, content []byte) error {
+	link, err := c.Presign(ctx, bucketName, key, probeExpiry)
+	if err != nil {
+		return fmt.Errorf("could not presign probe object: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not fetch probe object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not fetch probe object: unexpected status %s", resp.Status)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read probe object: %w", err)
+	}
+
+	if !bytes.Equal(b, content) {
+		return errors.New("fetched probe object does not match")
+	}
+
+	return nil
+}
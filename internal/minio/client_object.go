@@ -0,0 +1,103 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Metadata returns the user metadata value stored under name
+// for the specified object or an empty string if it is not set.
+func (c *Client) Metadata(
+	ctx context.Context,
+	bucketName string,
+	key string,
+	name string,
+) (string, error) {
+	info, err := c.client.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("could not stat object: %w", err)
+	}
+
+	// minio returns the user metadata keys in their
+	// canonical header form without the 'X-Amz-Meta-' prefix.
+	return info.UserMetadata[http.CanonicalHeaderKey(name)], nil
+}
+
+// ObjectExists reports whether the specified object exists.
+// A missing bucket is reported as a missing object.
+func (c *Client) ObjectExists(ctx context.Context, bucketName string, key string) (bool, error) {
+	_, err := c.client.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+	if err == nil {
+		return true, nil
+	}
+
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("could not stat object: %w", err)
+}
+
+// Remove deletes the specified object.
+func (c *Client) Remove(ctx context.Context, bucketName string, key string) error {
+	err := c.client.RemoveObject(ctx, bucketName, key, minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("could not remove object: %w", err)
+	}
+
+	return nil
+}
+
+// Bucket returns the name of either the public or private bucket.
+func (c *Client) Bucket(public bool) string {
+	if public {
+		return c.bucketPublic
+	}
+
+	return c.bucketPrivate
+}
+
+// Move moves the specified object into either the public or private bucket
+// using a server-side copy and returns the name of the destination bucket.
+func (c *Client) Move(ctx context.Context, bucketName string, key string, public bool) (string, error) {
+	if c.bucketPublic == "" || c.bucketPrivate == "" {
+		return "", errors.New("buckets not setup, run Setup() first")
+	}
+
+	dst := c.Bucket(public)
+	if dst == bucketName {
+		return "", fmt.Errorf("object is already in bucket '%s'", dst)
+	}
+
+	_, err := c.client.CopyObject(
+		ctx,
+		minio.CopyDestOptions{Bucket: dst, Object: key},
+		minio.CopySrcOptions{Bucket: bucketName, Object: key},
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not copy object: %w", err)
+	}
+
+	err = c.Remove(ctx, bucketName, key)
+	if err != nil {
+		return "", err
+	}
+
+	return dst, nil
+}
+
+// BucketExists reports whether the specified bucket exists. Since it
+// requires valid credentials it is useful to check the connection.
+func (c *Client) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+	exists, err := c.client.BucketExists(ctx, bucketName)
+	if err != nil {
+		return false, fmt.Errorf("could not check bucket: %w", err)
+	}
+
+	return exists, nil
+}
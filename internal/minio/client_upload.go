@@ -4,38 +4,51 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 )
 
+// UploadOptions configures an upload via Upload.
+type UploadOptions struct {
+	Public bool
+	// Metadata will be stored as user metadata on the object.
+	Metadata map[string]string
+}
+
+// UploadResult describes where an uploaded object has been stored.
+type UploadResult struct {
+	Bucket string
+	Key    string
+	Size   int64
+}
+
 // Upload uploads the specified file to either
-// the public or private bucket and creates a share link
-// with the specified expiry.
+// the public or private bucket.
+//
+// Use Link to create a share link for the uploaded object.
 func (c *Client) Upload(
 	ctx context.Context,
 	filePath string,
-	public bool,
-	expiry time.Duration,
-) (string, error) {
+	opts UploadOptions,
+) (*UploadResult, error) {
 	if c.bucketPublic == "" || c.bucketPrivate == "" {
-		return "", errors.New("buckets not setup, run Setup() first")
+		return nil, errors.New("buckets not setup, run Setup() first")
 	}
 
 	bucketName := c.bucketPrivate
-	if public {
+	if opts.Public {
 		bucketName = c.bucketPublic
 	}
 
 	fn, err := randomizeFileName(filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not randomize file name: %w", err)
+		return nil, fmt.Errorf("could not randomize file name: %w", err)
 	}
 
 	ct, err := findContentType(filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not find content type: %w", err)
+		return nil, fmt.Errorf("could not find content type: %w", err)
 	}
 
 	info, err := c.client.FPutObject(
@@ -43,23 +56,52 @@ func (c *Client) Upload(
 		bucketName,
 		fn,
 		filePath,
-		minio.PutObjectOptions{ContentType: ct},
+		minio.PutObjectOptions{ContentType: ct, UserMetadata: opts.Metadata},
 	)
 	if err != nil {
-		return "", fmt.Errorf("could not fput object: %w", err)
+		return nil, fmt.Errorf("could not fput object: %w", err)
+	}
+
+	return &UploadResult{Bucket: info.Bucket, Key: info.Key, Size: info.Size}, nil
+}
+
+// Link creates a share link for the specified object.
+//
+// Objects in the public bucket get a plain link, all other objects
+// get a presigned url with the specified expiry.
+func (c *Client) Link(
+	ctx context.Context,
+	bucketName string,
+	key string,
+	expiry time.Duration,
+) (string, error) {
+	if c.bucketPublic == "" || c.bucketPrivate == "" {
+		return "", errors.New("buckets not setup, run Setup() first")
 	}
 
 	// public object do not need a presigned url
 	// do we want one tho for content disposition purposes?
-	if public {
-		link := filepath.Join(c.client.EndpointURL().String(), bucketName, info.Key)
-		return link, nil
+	if bucketName == c.bucketPublic {
+		return c.client.EndpointURL().JoinPath(bucketName, key).String(), nil
 	}
 
+	return c.Presign(ctx, bucketName, key, expiry)
+}
+
+// MaxPresignExpiry is the maximum expiry of presigned urls (S3 signature v4).
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// Presign creates a presigned url for the specified object.
+func (c *Client) Presign(
+	ctx context.Context,
+	bucketName string,
+	key string,
+	expiry time.Duration,
+) (string, error) {
 	// no content disposition so far
-	link, err := c.client.PresignedGetObject(ctx, bucketName, info.Key, expiry, nil)
+	link, err := c.client.PresignedGetObject(ctx, bucketName, key, expiry, nil)
 	if err != nil {
-		return "", fmt.Errorf("could not egt presigned url: %w", err)
+		return "", fmt.Errorf("could not get presigned url: %w", err)
 	}
 
 	return link.String(), nil
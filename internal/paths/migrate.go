@@ -0,0 +1,175 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Legacy files stored in the '.data' and 'logs' dirs next to the executable,
+// other files in these dirs are left alone since the executable may be
+// installed in a shared dir like /usr/local/bin.
+const (
+	legacyDataPattern = ".minyls.data.json*"
+	legacyLogsPattern = "minyls_*.log.json*"
+)
+
+// migratedFileName is stored in the data dir once the legacy files have been
+// migrated, so the migration only runs once.
+const migratedFileName = ".minyls.migrated"
+
+// MigrateLegacy moves the files older versions of minyls stored next
+// to the executable (env file, data and logs) into the current locations.
+// Files already existing at the current locations are not overwritten.
+// It returns the paths of all moved files.
+//
+// It only runs once, successful migrations are recorded in the data dir.
+func MigrateLegacy() ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("could not find executable: %w", err)
+	}
+
+	exeDir := filepath.Dir(exe)
+
+	envFile, err := EnvFile()
+	if err != nil {
+		return nil, err
+	}
+
+	dataDir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	logsDir, err := LogsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	marker := filepath.Join(dataDir, migratedFileName)
+	if _, err = os.Stat(marker); err == nil {
+		return nil, nil
+	}
+
+	var moved []string
+
+	m, err := moveFile(filepath.Join(exeDir, ".minyls.env"), envFile)
+	if err != nil {
+		return moved, err
+	}
+	moved = append(moved, m...)
+
+	m, err = moveDirContents(filepath.Join(exeDir, ".data"), dataDir, legacyDataPattern)
+	if err != nil {
+		return moved, err
+	}
+	moved = append(moved, m...)
+
+	m, err = moveDirContents(filepath.Join(exeDir, "logs"), logsDir, legacyLogsPattern)
+	if err != nil {
+		return moved, err
+	}
+	moved = append(moved, m...)
+
+	err = os.MkdirAll(dataDir, 0700)
+	if err == nil {
+		err = os.WriteFile(marker, nil, 0600)
+	}
+	if err != nil {
+		return moved, fmt.Errorf("could not record migration: %w", err)
+	}
+
+	return moved, nil
+}
+
+// moveDirContents moves the files inside src matching pattern
+// (see filepath.Match) to dst and removes src if it is empty afterwards.
+func moveDirContents(src string, dst string, pattern string) ([]string, error) {
+	if src == dst {
+		return nil, nil
+	}
+
+	files, err := os.ReadDir(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read legacy dir: %w", err)
+	}
+
+	var moved []string
+	for _, file := range files {
+		if ok, _ := filepath.Match(pattern, file.Name()); !ok || file.IsDir() {
+			continue
+		}
+
+		m, err := moveFile(filepath.Join(src, file.Name()), filepath.Join(dst, file.Name()))
+		if err != nil {
+			return moved, err
+		}
+		moved = append(moved, m...)
+	}
+
+	// only removes the dir if it is empty
+	_ = os.Remove(src)
+
+	return moved, nil
+}
+
+// moveFile moves src to dst unless src does not exist or dst already exists.
+func moveFile(src string, dst string) ([]string, error) {
+	if src == dst {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		return nil, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(dst), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("could not create dir: %w", err)
+	}
+
+	err = os.Rename(src, dst)
+	if err != nil {
+		// renaming fails across file systems
+		err = copyFile(src, dst)
+		if err != nil {
+			return nil, fmt.Errorf("could not move '%s': %w", src, err)
+		}
+
+		_ = os.Remove(src)
+	}
+
+	return []string{dst}, nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,112 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appName = "minyls"
+
+// homeEnv overrides all other locations if set. The config will be stored
+// directly in it, the data in 'data' and the logs in 'logs'.
+const homeEnv = "MINYLS_HOME"
+
+// ConfigDir returns the directory of the config files.
+// It defaults to $XDG_CONFIG_HOME/minyls (see os.UserConfigDir).
+func ConfigDir() (string, error) {
+	if home := os.Getenv(homeEnv); home != "" {
+		return home, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user config dir: %w", err)
+	}
+
+	return filepath.Join(dir, appName), nil
+}
+
+// DataDir returns the directory of the history (data) files.
+// It defaults to $XDG_DATA_HOME/minyls on unix systems.
+func DataDir() (string, error) {
+	if home := os.Getenv(homeEnv); home != "" {
+		return filepath.Join(home, "data"), nil
+	}
+
+	return userDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// LogsDir returns the directory of the log files.
+// It defaults to $XDG_STATE_HOME/minyls/logs on unix systems.
+func LogsDir() (string, error) {
+	if home := os.Getenv(homeEnv); home != "" {
+		return filepath.Join(home, "logs"), nil
+	}
+
+	dir, err := userDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "logs"), nil
+}
+
+// EnvFile returns the path of the env file.
+func EnvFile() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "minyls.env"), nil
+}
+
+// userDir returns the minyls directory inside the directory set by xdgEnv
+// or inside fallback relative to the home directory. Windows and macOS
+// do not use the xdg directories, the local app data and application
+// support directories are used instead.
+func userDir(xdgEnv string, fallback string) (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		dir, err := os.UserCacheDir() // %LocalAppData%
+		if err != nil {
+			return "", fmt.Errorf("could not find local app data dir: %w", err)
+		}
+
+		return filepath.Join(dir, appName), nil
+	case "darwin", "ios":
+		return ConfigDir() // ~/Library/Application Support
+	}
+
+	if dir := os.Getenv(xdgEnv); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", fmt.Errorf("$%s must be an absolute path", xdgEnv)
+		}
+
+		return filepath.Join(dir, appName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user home dir: %w", err)
+	}
+
+	if home == "" {
+		return "", errors.New("user home dir is empty")
+	}
+
+	return filepath.Join(home, fallback, appName), nil
+}
+
+// ConfigFile returns the path of the config file containing the profiles.
+func ConfigFile() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.toml"), nil
+}
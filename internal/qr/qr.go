@@ -0,0 +1,132 @@
+package qr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// DefaultSize is the default width and height of PNG images in pixels.
+const DefaultSize = 256
+
+// Terminal writes content as QR code to w using unicode half blocks,
+// so each line of text contains two rows of the code. The colors are
+// set explicitly to work on both dark and light terminals.
+func Terminal(w io.Writer, content string) error {
+	bitmap, err := encode(content)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for y := 0; y < len(bitmap); y += 2 {
+		// white foreground on black background
+		bw.WriteString("\x1b[97;40m")
+
+		for x := range bitmap[y] {
+			top := !bitmap[y][x]
+			bottom := y+1 < len(bitmap) && !bitmap[y+1][x]
+
+			switch {
+			case top && bottom:
+				bw.WriteString("█")
+			case top:
+				bw.WriteString("▀")
+			case bottom:
+				bw.WriteString("▄")
+			default:
+				bw.WriteString(" ")
+			}
+		}
+
+		bw.WriteString("\x1b[0m\n")
+	}
+
+	return bw.Flush()
+}
+
+// PNG writes content as QR code PNG image of size x size pixels to w.
+func PNG(w io.Writer, content string, size int) error {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return fmt.Errorf("could not encode qr code: %w", err)
+	}
+
+	return q.Write(size, w)
+}
+
+// SVG writes content as QR code SVG image to w, one unit per module.
+func SVG(w io.Writer, content string) error {
+	bitmap, err := encode(content)
+	if err != nil {
+		return err
+	}
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	_, err = fmt.Fprintf(w, svgTemplate, len(bitmap), path.String())
+	return err
+}
+
+const svgTemplate = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %[1]d %[1]d" shape-rendering="crispEdges">` +
+	`<rect width="%[1]d" height="%[1]d" fill="#fff"/><path d="%[2]s" fill="#000"/></svg>` + "\n"
+
+// CheckFile returns an error if the format of path is not supported by WriteFile.
+func CheckFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".svg":
+		return nil
+	default:
+		return fmt.Errorf("unsupported qr code file '%s' (expected .png or .svg)", path)
+	}
+}
+
+// WriteFile writes content as QR code to path, the
+// format is chosen by its extension (.png or .svg).
+func WriteFile(path string, content string, size int) error {
+	err := CheckFile(path)
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error { return SVG(w, content) }
+	if strings.ToLower(filepath.Ext(path)) == ".png" {
+		write = func(w io.Writer) error { return PNG(w, content, size) }
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+
+	err = write(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not write qr code: %w", err)
+	}
+
+	return f.Close()
+}
+
+// encode returns the modules of the QR code including the quiet zone.
+func encode(content string) ([][]bool, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode qr code: %w", err)
+	}
+
+	return q.Bitmap(), nil
+}
@@ -0,0 +1,355 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketMeta       = []byte("meta")
+	bucketEntries    = []byte("entries")
+	bucketIdxToken   = []byte("idx_token")
+	bucketIdxKeyword = []byte("idx_keyword")
+	bucketIdxExpiry  = []byte("idx_expiry")
+	bucketIdxTag     = []byte("idx_tag")
+)
+
+// boltStore stores the entries in an embedded bolt database with
+// indexes by token, keyword, expiry and tag. The database is opened per
+// operation since bolt locks the file, e.g. a running gateway would
+// otherwise block every other minyls invocation.
+type boltStore struct {
+	path string
+}
+
+func openBoltStore(path string, legacyJSONPath string) (*boltStore, error) {
+	s := &boltStore{path: path}
+
+	err := backupBolt(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backup database: %w", err)
+	}
+
+	err = s.update(func(tx *bolt.Tx) error {
+		if err := migrateBolt(tx); err != nil {
+			return fmt.Errorf("failed to migrate: %w", err)
+		}
+
+		return importJSON(tx, legacyJSONPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *boltStore) Write(entry *DataEntry) error {
+	return s.update(func(tx *bolt.Tx) error {
+		id, err := tx.Bucket(bucketEntries).NextSequence()
+		if err != nil {
+			return fmt.Errorf("failed to get next id: %w", err)
+		}
+
+		entry.ID = int(id) //nolint:gosec // ids fit into int
+		entry.Timestamp = time.Now()
+
+		return putEntry(tx, entry)
+	})
+}
+
+func (s *boltStore) Update(id int, fn func(entry *DataEntry) error) (*DataEntry, error) {
+	var entry *DataEntry
+
+	err := s.update(func(tx *bolt.Tx) error {
+		// the unmodified entry is needed to remove the outdated index keys
+		old, err := getEntry(tx, idKey(id))
+		if err != nil {
+			return err
+		}
+
+		entry, err = getEntry(tx, idKey(id))
+		if err != nil {
+			return err
+		}
+
+		if err = fn(entry); err != nil {
+			return err
+		}
+
+		// the id is the primary key and may not change
+		entry.ID = id
+
+		if err = unindexEntry(tx, old); err != nil {
+			return err
+		}
+
+		return putEntry(tx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *boltStore) All() ([]*DataEntry, error) {
+	var entries []*DataEntry
+
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketEntries).ForEach(func(_, v []byte) error {
+			entry, err := decodeEntry(v)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *boltStore) FindByID(id int) (*DataEntry, error) {
+	var entry *DataEntry
+
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		entry, err = getEntry(tx, idKey(id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *boltStore) FindByToken(token string) (*DataEntry, error) {
+	return s.findByIndex(bucketIdxToken, []byte(token))
+}
+
+// FindByKeyword returns the entry with the lowest id like jsonStore, since
+// keywords are not unique, e.g. object names if the shortener is disabled.
+func (s *boltStore) FindByKeyword(keyword string) (*DataEntry, error) {
+	prefix := indexPrefix(keyword)
+
+	entries, err := s.scanIndex(bucketIdxKeyword, prefix, func(k []byte) bool {
+		return len(k) == len(prefix)+idKeyLen && bytes.HasPrefix(k, prefix)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrEntryNotFound
+	}
+
+	return entries[0], nil
+}
+
+func (s *boltStore) FindByTag(tag string) ([]*DataEntry, error) {
+	prefix := indexPrefix(tag)
+
+	return s.scanIndex(bucketIdxTag, prefix, func(k []byte) bool {
+		return len(k) == len(prefix)+idKeyLen && bytes.HasPrefix(k, prefix)
+	})
+}
+
+func (s *boltStore) FindExpiringBefore(t time.Time) ([]*DataEntry, error) {
+	limit := timeKey(t)
+
+	return s.scanIndex(bucketIdxExpiry, nil, func(k []byte) bool {
+		return bytes.Compare(k[:idKeyLen], limit) < 0
+	})
+}
+
+// Close is a no-op since the database is opened per operation.
+func (s *boltStore) Close() error {
+	return nil
+}
+
+func (s *boltStore) findByIndex(bucket []byte, key []byte) (*DataEntry, error) {
+	var entry *DataEntry
+
+	err := s.view(func(tx *bolt.Tx) error {
+		id := tx.Bucket(bucket).Get(key)
+		if id == nil {
+			return ErrEntryNotFound
+		}
+
+		var err error
+		entry, err = getEntry(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// scanIndex iterates the index bucket starting at seek while match
+// returns true and returns the referenced entries in index order.
+func (s *boltStore) scanIndex(bucket []byte, seek []byte, match func(k []byte) bool) ([]*DataEntry, error) {
+	var entries []*DataEntry
+
+	err := s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+
+		k, id := c.First()
+		if seek != nil {
+			k, id = c.Seek(seek)
+		}
+
+		for ; k != nil && match(k); k, id = c.Next() {
+			entry, err := getEntry(tx, id)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *boltStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	return db.View(fn)
+}
+
+func (s *boltStore) update(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	err = db.Update(fn)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	return db.Close()
+}
+
+func getEntry(tx *bolt.Tx, id []byte) (*DataEntry, error) {
+	v := tx.Bucket(bucketEntries).Get(id)
+	if v == nil {
+		return nil, ErrEntryNotFound
+	}
+
+	return decodeEntry(v)
+}
+
+func decodeEntry(v []byte) (*DataEntry, error) {
+	entry := &DataEntry{}
+
+	err := json.Unmarshal(v, entry)
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+
+	return entry, nil
+}
+
+// putEntry stores entry and adds its index keys.
+func putEntry(tx *bolt.Tx, entry *DataEntry) error {
+	v, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode failed: %w", err)
+	}
+
+	id := idKey(entry.ID)
+
+	err = tx.Bucket(bucketEntries).Put(id, v)
+	if err != nil {
+		return fmt.Errorf("failed to put entry: %w", err)
+	}
+
+	return forEachIndexKey(entry, func(bucket []byte, key []byte) error {
+		return tx.Bucket(bucket).Put(key, id)
+	})
+}
+
+func unindexEntry(tx *bolt.Tx, entry *DataEntry) error {
+	return forEachIndexKey(entry, func(bucket []byte, key []byte) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+// forEachIndexKey calls fn with every index bucket and key of entry.
+func forEachIndexKey(entry *DataEntry, fn func(bucket []byte, key []byte) error) error {
+	id := idKey(entry.ID)
+
+	var keys [][2][]byte
+
+	// entries which never expire are not part of the expiry index
+	if !entry.NeverExpires() {
+		keys = append(keys, [2][]byte{bucketIdxExpiry, append(timeKey(entry.ExpiresAt()), id...)})
+	}
+
+	if entry.Token != "" {
+		keys = append(keys, [2][]byte{bucketIdxToken, []byte(entry.Token)})
+	}
+
+	if keyword := entry.Keyword(); keyword != "" {
+		keys = append(keys, [2][]byte{bucketIdxKeyword, append(indexPrefix(keyword), id...)})
+	}
+
+	for _, tag := range entry.Tags {
+		keys = append(keys, [2][]byte{bucketIdxTag, append(indexPrefix(tag), id...)})
+	}
+
+	for _, k := range keys {
+		if err := fn(k[0], k[1]); err != nil {
+			return fmt.Errorf("failed to update index '%s': %w", k[0], err)
+		}
+	}
+
+	return nil
+}
+
+const idKeyLen = 8
+
+// idKey encodes id big endian to keep the keys ordered by id.
+func idKey(id int) []byte {
+	b := make([]byte, idKeyLen)
+	binary.BigEndian.PutUint64(b, uint64(id)) //nolint:gosec // ids are never negative
+	return b
+}
+
+// timeKey encodes t big endian to keep the keys ordered by time.
+// Times before the unix epoch are stored as the epoch.
+func timeKey(t time.Time) []byte {
+	b := make([]byte, idKeyLen)
+	binary.BigEndian.PutUint64(b, uint64(max(t.UnixNano(), 0)))
+	return b
+}
+
+// indexPrefix separates the indexed value (tag or keyword) from the id with
+// a zero byte so values being prefixes of others do not match.
+func indexPrefix(value string) []byte {
+	return append([]byte(value), 0)
+}
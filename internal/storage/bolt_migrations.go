@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	keySchemaVersion = []byte("schema_version")
+	keyJSONImported  = []byte("json_imported")
+)
+
+// boltMigrations upgrade the database schema step by step. The schema
+// version is the number of applied migrations, so new migrations
+// always need to be appended.
+var boltMigrations = []func(tx *bolt.Tx) error{
+	// 1: entries and their indexes
+	func(tx *bolt.Tx) error {
+		buckets := [][]byte{
+			bucketEntries,
+			bucketIdxToken,
+			bucketIdxKeyword,
+			bucketIdxExpiry,
+			bucketIdxTag,
+		}
+
+		for _, b := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return fmt.Errorf("failed to create bucket '%s': %w", b, err)
+			}
+		}
+
+		return nil
+	},
+	// 2: resolve bucket and object of entries imported from older json data
+	func(tx *bolt.Tx) error {
+		return migrateRawEntries(tx, migrateEntryObject)
+	},
+	// 3: index keywords by keyword and id since they are not unique
+	// and remove entries which never expire from the expiry index
+	func(tx *bolt.Tx) error {
+		return rebuildIndexes(tx, bucketIdxKeyword, bucketIdxExpiry)
+	},
+}
+
+// migrateBolt applies all migrations missing in the database.
+func migrateBolt(tx *bolt.Tx) error {
+	meta, err := tx.CreateBucketIfNotExists(bucketMeta)
+	if err != nil {
+		return fmt.Errorf("failed to create meta bucket: %w", err)
+	}
+
+	version := boltVersion(tx)
+	if version > len(boltMigrations) {
+		return fmt.Errorf("%w (schema version %d, supported %d)", ErrNewerVersion, version, len(boltMigrations))
+	}
+
+	for i := version; i < len(boltMigrations); i++ {
+		if err = boltMigrations[i](tx); err != nil {
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+	}
+
+	return meta.Put(keySchemaVersion, idKey(len(boltMigrations)))
+}
+
+// boltVersion returns the schema version of the database.
+func boltVersion(tx *bolt.Tx) int {
+	meta := tx.Bucket(bucketMeta)
+	if meta == nil {
+		return 0
+	}
+
+	v := meta.Get(keySchemaVersion)
+	if v == nil {
+		return 0
+	}
+
+	return int(binary.BigEndian.Uint64(v)) //nolint:gosec // versions fit into int
+}
+
+// backupBolt copies the database at path to path.v<version> before it gets
+// migrated. New databases and databases with the current version are skipped.
+func backupBolt(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		version := boltVersion(tx)
+		if version == 0 || version >= len(boltMigrations) {
+			return nil
+		}
+
+		return tx.CopyFile(path+".v"+strconv.Itoa(version), 0600)
+	})
+}
+
+// migrateRawEntries applies fn to the raw json of every entry.
+func migrateRawEntries(tx *bolt.Tx, fn func(entry map[string]any)) error {
+	b := tx.Bucket(bucketEntries)
+
+	updated := map[string][]byte{}
+
+	err := b.ForEach(func(k, v []byte) error {
+		entry := map[string]any{}
+
+		// numbers need to stay exact, e.g. expiries in nanoseconds
+		decoder := json.NewDecoder(bytes.NewReader(v))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&entry); err != nil {
+			return fmt.Errorf("decode failed: %w", err)
+		}
+
+		fn(entry)
+
+		nv, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("encode failed: %w", err)
+		}
+
+		updated[string(k)] = nv
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the bucket may not be modified while iterating it
+	for k, v := range updated {
+		if err = b.Put([]byte(k), v); err != nil {
+			return fmt.Errorf("failed to put entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rebuildIndexes recreates the index buckets from the index keys of all entries.
+func rebuildIndexes(tx *bolt.Tx, buckets ...[]byte) error {
+	for _, b := range buckets {
+		if err := tx.DeleteBucket(b); err != nil {
+			return fmt.Errorf("failed to delete bucket '%s': %w", b, err)
+		}
+
+		if _, err := tx.CreateBucket(b); err != nil {
+			return fmt.Errorf("failed to create bucket '%s': %w", b, err)
+		}
+	}
+
+	return tx.Bucket(bucketEntries).ForEach(func(k, v []byte) error {
+		entry := &DataEntry{}
+		if err := json.Unmarshal(v, entry); err != nil {
+			return fmt.Errorf("decode failed: %w", err)
+		}
+
+		return forEachIndexKey(entry, func(bucket []byte, key []byte) error {
+			if !slices.ContainsFunc(buckets, func(b []byte) bool { return bytes.Equal(b, bucket) }) {
+				return nil
+			}
+
+			return tx.Bucket(bucket).Put(key, k)
+		})
+	})
+}
+
+// importJSON imports the entries of the json data file used before
+// the bolt store once. The json file itself is kept as a backup.
+func importJSON(tx *bolt.Tx, path string) error {
+	meta := tx.Bucket(bucketMeta)
+	if meta.Get(keyJSONImported) != nil {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return meta.Put(keyJSONImported, []byte{1})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open json data: %w", err)
+	}
+	defer f.Close()
+
+	d, err := decodeData(f)
+	if err != nil {
+		return fmt.Errorf("failed to import json data: %w", err)
+	}
+
+	entries := tx.Bucket(bucketEntries)
+	for _, entry := range d.Entries {
+		if err = putEntry(tx, entry); err != nil {
+			return fmt.Errorf("failed to import entry %d: %w", entry.ID, err)
+		}
+
+		// keep the imported ids, new entries continue after them
+		if uint64(entry.ID) > entries.Sequence() { //nolint:gosec // ids are never negative
+			if err = entries.SetSequence(uint64(entry.ID)); err != nil { //nolint:gosec // see above
+				return fmt.Errorf("failed to set sequence: %w", err)
+			}
+		}
+	}
+
+	return meta.Put(keyJSONImported, []byte{1})
+}
@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// jsonStore stores all entries in a single json file.
+// The file is reloaded on every access to pick up
+// changes made by other processes.
+//
+// Writes replace the file atomically and keep
+// the previous versions as rotating backups.
+//
+// Every access holds an advisory lock on a separate lock file, so
+// concurrent minyls processes never lose entries or assign duplicate ids.
+type jsonStore struct {
+	mu   sync.Mutex
+	path string
+	data *Data
+}
+
+func openJSONStore(path string) (*jsonStore, error) {
+	s := &jsonStore{path: path}
+
+	unlock, err := lockFile(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err = migrateJSONFile(path); err != nil {
+		return nil, fmt.Errorf("failed to migrate data: %w", err)
+	}
+
+	if err = s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load data: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *jsonStore) Write(entry *DataEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// reload first to not overwrite entries
+	// written by other processes in the meantime
+	if err := s.load(); err != nil {
+		return fmt.Errorf("failed to load data: %w", err)
+	}
+
+	entry.ID = s.findLatestID() + 1
+	entry.Timestamp = time.Now()
+	s.data.Entries = append(s.data.Entries, entry)
+
+	return s.write()
+}
+
+func (s *jsonStore) Update(id int, fn func(entry *DataEntry) error) (*DataEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load data: %w", err)
+	}
+
+	i := slices.IndexFunc(s.data.Entries, func(e *DataEntry) bool { return e.ID == id })
+	if i == -1 {
+		return nil, ErrEntryNotFound
+	}
+
+	entry := s.data.Entries[i]
+	if err = fn(entry); err != nil {
+		return nil, err
+	}
+
+	return entry, s.write()
+}
+
+func (s *jsonStore) All() ([]*DataEntry, error) {
+	return s.filter(func(*DataEntry) bool { return true })
+}
+
+func (s *jsonStore) FindByID(id int) (*DataEntry, error) {
+	return s.find(func(e *DataEntry) bool { return e.ID == id })
+}
+
+func (s *jsonStore) FindByToken(token string) (*DataEntry, error) {
+	return s.find(func(e *DataEntry) bool { return e.Token == token })
+}
+
+func (s *jsonStore) FindByKeyword(keyword string) (*DataEntry, error) {
+	return s.find(func(e *DataEntry) bool { return e.Keyword() == keyword })
+}
+
+func (s *jsonStore) FindByTag(tag string) ([]*DataEntry, error) {
+	return s.filter(func(e *DataEntry) bool { return e.HasTag(tag) })
+}
+
+func (s *jsonStore) FindExpiringBefore(t time.Time) ([]*DataEntry, error) {
+	entries, err := s.filter(func(e *DataEntry) bool { return !e.NeverExpires() && e.ExpiresAt().Before(t) })
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(entries, func(a, b *DataEntry) int {
+		return a.ExpiresAt().Compare(b.ExpiresAt())
+	})
+
+	return entries, nil
+}
+
+// Close is a no-op since the file is opened per operation.
+func (s *jsonStore) Close() error {
+	return nil
+}
+
+func (s *jsonStore) find(match func(e *DataEntry) bool) (*DataEntry, error) {
+	entries, err := s.filter(match)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrEntryNotFound
+	}
+
+	return entries[0], nil
+}
+
+// filter reloads the data and returns all matching entries.
+func (s *jsonStore) filter(match func(e *DataEntry) bool) ([]*DataEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load data: %w", err)
+	}
+
+	var entries []*DataEntry
+	for _, e := range s.data.Entries {
+		if match(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func (s *jsonStore) findLatestID() int {
+	latest := 0
+	for _, e := range s.data.Entries {
+		if e.ID > latest {
+			latest = e.ID
+		}
+	}
+	return latest
+}
+
+func (s *jsonStore) load() error {
+	d, err := readJSONFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.data = d
+	return nil
+}
+
+func (s *jsonStore) write() error {
+	s.data.Version = dataVersion
+
+	b, err := json.Marshal(s.data)
+	if err != nil {
+		return fmt.Errorf("encode failed: %w", err)
+	}
+
+	return writeJSONFile(s.path, b)
+}
+
+// readJSONFile reads the data of the json file at path,
+// a missing file results in empty data.
+func readJSONFile(path string) (*Data, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Data{Entries: []*DataEntry{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open failed: %w", err)
+	}
+	defer f.Close()
+
+	return decodeData(f)
+}
+
+// jsonBackups is the number of backups kept of the json file.
+const jsonBackups = 5
+
+// writeJSONFile replaces the file at path with b. The data is written to a
+// temporary file and synced before it is renamed, so a crash never leaves
+// a partially written file behind. The previous file is kept as the
+// first backup (path.1), older backups are rotated up to path.N.
+func writeJSONFile(path string, b []byte) error {
+	tmp := path + ".tmp"
+
+	err := writeSynced(tmp, b)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	err = rotateBackups(path)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename failed: %w", err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+func writeSynced(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("open failed: %w", err)
+	}
+
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("write failed: %w", err)
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync failed: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("close failed: %w", err)
+	}
+
+	return nil
+}
+
+// rotateBackups shifts the backups of path by one and makes
+// the current file the first backup. The current file stays
+// in place so there is always a file at path.
+func rotateBackups(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	for i := jsonBackups - 1; i > 0; i-- {
+		err := os.Rename(backupPath(path, i), backupPath(path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	first := backupPath(path, 1)
+
+	// a hard link is cheap, copy the file if links are not supported
+	err := os.Link(path, first)
+	if err == nil {
+		return nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return writeSynced(first, b)
+}
+
+func backupPath(path string, i int) string {
+	return path + "." + strconv.Itoa(i)
+}
+
+// syncDir syncs the directory to persist renames.
+// Not every platform supports syncing directories,
+// so errors are ignored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil //nolint:nilerr // see above
+	}
+	defer d.Close()
+
+	_ = d.Sync()
+	return nil
+}
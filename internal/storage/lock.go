@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// lockTimeout is the maximum time to wait for other
+// processes holding a lock on the data.
+const lockTimeout = 10 * time.Second
+
+const lockRetryDelay = 50 * time.Millisecond
+
+// lockFile acquires an advisory lock for the file at path using a separate
+// lock file, since the file itself gets replaced on writes. Exclusive locks
+// are needed for read-modify-write, shared locks for reads.
+// The returned func releases the lock.
+func lockFile(path string, exclusive bool) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	fl := flock.New(path + ".lock")
+
+	tryLock := fl.TryRLockContext
+	if exclusive {
+		tryLock = fl.TryLockContext
+	}
+
+	ok, err := tryLock(ctx, lockRetryDelay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock data file: %w", err)
+	}
+
+	if !ok {
+		return nil, errors.New("failed to lock data file: locked by another process")
+	}
+
+	return func() {
+		// the lock is released by the os once the process exits anyway
+		_ = fl.Unlock()
+	}, nil
+}
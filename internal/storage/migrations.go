@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// dataMigrations upgrade the json data format step by step, the migration
+// at index i upgrades version i to i+1. Files written before the version
+// was introduced have version 0. New migrations always need to be appended.
+//
+// Migrations work on the raw json since older formats
+// may not be representable by the current types.
+var dataMigrations = []func(data map[string]any) error{
+	// 1: resolve bucket and object of entries created before both were stored
+	func(data map[string]any) error {
+		return forEachRawEntry(data, migrateEntryObject)
+	},
+}
+
+// dataVersion is the version of the data format written by this version of minyls.
+var dataVersion = len(dataMigrations)
+
+// ErrCorrupted is returned if the json data file cannot be decoded.
+var ErrCorrupted = errors.New("data file is corrupted, run 'minyls history repair'")
+
+// ErrNewerVersion is returned if the data has been written by a newer
+// version of minyls. Downgrading the data is not supported.
+var ErrNewerVersion = errors.New("data was written by a newer version of minyls")
+
+// decodeData decodes json data of any known version and migrates
+// it to the current version, an empty input results in empty data.
+func decodeData(r io.Reader) (*Data, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+
+	version, err := peekVersion(b)
+	if errors.Is(err, io.EOF) {
+		return &Data{Version: dataVersion, Entries: []*DataEntry{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if version != dataVersion {
+		b, err = migrateData(b, version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Data{}
+	if err = json.Unmarshal(b, d); err != nil {
+		return nil, fmt.Errorf("decode failed: %w: %w", ErrCorrupted, err)
+	}
+
+	if d.Entries == nil {
+		d.Entries = []*DataEntry{}
+	}
+
+	return d, nil
+}
+
+// peekVersion returns the version of the json data
+// or io.EOF if the data is empty.
+func peekVersion(b []byte) (int, error) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return 0, io.EOF
+	}
+
+	v := struct {
+		Version int `json:"version"`
+	}{}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return 0, fmt.Errorf("decode failed: %w: %w", ErrCorrupted, err)
+	}
+
+	if v.Version > dataVersion {
+		return 0, fmt.Errorf("%w (version %d, supported %d)", ErrNewerVersion, v.Version, dataVersion)
+	}
+
+	return v.Version, nil
+}
+
+// migrateData applies all migrations after version to the json data.
+func migrateData(b []byte, version int) ([]byte, error) {
+	data := map[string]any{}
+
+	// numbers need to stay exact, e.g. expiries in nanoseconds
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode failed: %w: %w", ErrCorrupted, err)
+	}
+
+	for i := version; i < dataVersion; i++ {
+		if err := dataMigrations[i](data); err != nil {
+			return nil, fmt.Errorf("migration to version %d failed: %w", i+1, err)
+		}
+	}
+
+	data["version"] = dataVersion
+
+	return json.Marshal(data)
+}
+
+// migrateJSONFile migrates the json file at path to the current version.
+// The file is kept as a backup (path.v<version>) before it gets replaced.
+func migrateJSONFile(path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+
+	version, err := peekVersion(b)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if version == dataVersion {
+		return nil
+	}
+
+	err = writeSynced(path+".v"+strconv.Itoa(version), b)
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	d, err := decodeData(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	b, err = json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("encode failed: %w", err)
+	}
+
+	return writeJSONFile(path, b)
+}
+
+func forEachRawEntry(data map[string]any, fn func(entry map[string]any)) error {
+	raw, ok := data["entries"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	entries, ok := raw.([]any)
+	if !ok {
+		return errors.New("entries are not a list")
+	}
+
+	for _, e := range entries {
+		entry, ok := e.(map[string]any)
+		if !ok {
+			return errors.New("entry is not an object")
+		}
+
+		fn(entry)
+	}
+
+	return nil
+}
+
+func migrateEntryObject(entry map[string]any) {
+	if bucket, _ := entry["bucket"].(string); bucket != "" {
+		return
+	}
+
+	link, _ := entry["minio_link"].(string)
+
+	bucket, key, ok := objectFromLink(link)
+	if !ok {
+		return
+	}
+
+	entry["bucket"] = bucket
+	entry["object"] = key
+}
+
+// objectFromLink returns the bucket and key of a link to an object.
+func objectFromLink(link string) (string, string, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", false
+	}
+
+	// older public links lost the host separator ('http:/host/bucket/key')
+	p := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" {
+		_, p, _ = strings.Cut(p, "/")
+	}
+
+	bucket, key, ok := strings.Cut(p, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", false
+	}
+
+	return bucket, key, true
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RepairJSON restores the json data file from the newest valid backup
+// if it cannot be decoded. The corrupted file is kept next to it.
+// It returns a description of what has been done.
+//
+// It only repairs the data file of the json backend and does not need
+// Open to be called first since Open fails for corrupted data files.
+func RepairJSON() (string, error) {
+	dir, err := prepareStorageDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare storage: %w", err)
+	}
+
+	path := filepath.Join(dir, jsonFileName)
+
+	unlock, err := lockFile(path, true)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	_, err = readJSONFile(path)
+	if err == nil {
+		return "data file is valid, nothing to repair", nil
+	}
+	if !errors.Is(err, ErrCorrupted) {
+		return "", err
+	}
+
+	for i := 1; i <= jsonBackups; i++ {
+		bp := backupPath(path, i)
+
+		b, err := os.ReadFile(bp)
+		if err != nil {
+			continue
+		}
+
+		if _, err = decodeData(bytes.NewReader(b)); err != nil {
+			continue
+		}
+
+		corrupted := path + ".corrupted-" + time.Now().Format("2006-01-02_15-04-05")
+
+		err = os.Rename(path, corrupted)
+		if err != nil {
+			return "", fmt.Errorf("failed to move corrupted data file: %w", err)
+		}
+
+		err = writeJSONFile(path, b)
+		if err != nil {
+			return "", fmt.Errorf("failed to restore backup: %w", err)
+		}
+
+		return fmt.Sprintf("restored data file from %s, corrupted file moved to %s", bp, corrupted), nil
+	}
+
+	return "", errors.New("no valid backup found")
+}
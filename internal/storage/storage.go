@@ -1,16 +1,22 @@
 package storage
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/devusSs/minyls/internal/paths"
 )
 
 type Data struct {
+	// Version is the version of the data format, see dataMigrations.
+	Version int          `json:"version"`
 	Entries []*DataEntry `json:"entries"`
 }
 
@@ -20,30 +26,101 @@ type DataEntry struct {
 	MinioLink  string        `json:"minio_link"`
 	YOURLSLink string        `json:"yourls_link"`
 	Expiry     time.Duration `json:"expiry"`
+	Bucket     string        `json:"bucket,omitempty"`
+	Object     string        `json:"object,omitempty"`
+	// OriginalName is the name of the uploaded file.
+	OriginalName string `json:"original_name,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	// Title and Notes describe the upload, the title is
+	// also used for the short link if it is set.
+	Title string `json:"title,omitempty"`
+	Notes string `json:"notes,omitempty"`
+	// SHA256 is the hex encoded hash of the uploaded file.
+	SHA256 string   `json:"sha256,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	// Token identifies the entry on the gateway (minyls serve),
+	// it is empty for entries which are not served via the gateway.
+	Token string `json:"token,omitempty"`
+	// Protected marks the entry as password protected,
+	// the password hash itself is stored on the object.
+	Protected bool `json:"protected,omitempty"`
+	// MaxDownloads limits the downloads via the gateway, 0 means unlimited.
+	MaxDownloads int       `json:"max_downloads,omitempty"`
+	Downloads    int       `json:"downloads,omitempty"`
+	LastDownload time.Time `json:"last_download,omitzero"`
+	// Deleted marks entries whose object has already been deleted.
+	Deleted bool `json:"deleted,omitempty"`
+	// Revoked marks entries the gateway should no longer serve.
+	Revoked bool `json:"revoked,omitempty"`
+}
+
+// backend persists the data entries. Implementations need to be
+// safe for concurrent use and pick up changes of other processes.
+type backend interface {
+	// Write assigns a new id and timestamp to entry and stores it.
+	Write(entry *DataEntry) error
+	// Update applies fn to the entry with the specified id and stores it.
+	// Nothing is stored if fn returns an error.
+	Update(id int, fn func(entry *DataEntry) error) (*DataEntry, error)
+	// All returns all entries ordered by id.
+	All() ([]*DataEntry, error)
+	FindByID(id int) (*DataEntry, error)
+	FindByToken(token string) (*DataEntry, error)
+	FindByKeyword(keyword string) (*DataEntry, error)
+	// FindByTag returns all entries with the specified tag ordered by id.
+	FindByTag(tag string) ([]*DataEntry, error)
+	// FindExpiringBefore returns all entries expiring before t ordered by expiry.
+	FindExpiringBefore(t time.Time) ([]*DataEntry, error)
+	Close() error
 }
 
-var (
-	storageDir  = ".data"
-	storagePath string
-	storageFile *os.File
-	currentData *Data
+// Available storage backends.
+const (
+	BackendBolt = "bolt"
+	BackendJSON = "json"
 )
 
-func Init(expiry time.Duration) error {
-	if err := prepareStorage(); err != nil {
-		return fmt.Errorf("failed to prepare storage: %w", err)
-	}
+// Store stores the data entries using one of the backends.
+// It is safe for concurrent use, also across processes.
+type Store struct {
+	b backend
+}
 
-	if err := loadData(); err != nil {
-		return fmt.Errorf("failed to load data: %w", err)
+const (
+	jsonFileName = ".minyls.data.json"
+	boltFileName = ".minyls.data.db"
+)
+
+// Open opens the store of the specified backend. Expired entries are kept
+// so their links can be regenerated later on.
+//
+// The bolt backend imports the entries of an existing
+// json data file on first run.
+func Open(backendName string) (*Store, error) {
+	dir, err := prepareStorageDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare storage: %w", err)
 	}
 
-	removeExpiredEntries(expiry)
+	jsonPath := filepath.Join(dir, jsonFileName)
 
-	return nil
+	var b backend
+	switch backendName {
+	case BackendBolt:
+		b, err = openBoltStore(filepath.Join(dir, boltFileName), jsonPath)
+	case BackendJSON:
+		b, err = openJSONStore(jsonPath)
+	default:
+		return nil, fmt.Errorf("unknown storage backend '%s'", backendName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s store: %w", backendName, err)
+	}
+
+	return &Store{b: b}, nil
 }
 
-func WriteEntry(entry *DataEntry) error {
+func (s *Store) WriteEntry(entry *DataEntry) error {
 	if entry == nil {
 		return errors.New("entry cannot be nil")
 	}
@@ -52,104 +129,180 @@ func WriteEntry(entry *DataEntry) error {
 		return fmt.Errorf("entry validation failed: %w", err)
 	}
 
-	entry.ID = findLatestID() + 1
-	entry.Timestamp = time.Now()
-	currentData.Entries = append(currentData.Entries, entry)
-
-	return writeData()
+	return s.b.Write(entry)
 }
 
-func Read() (*Data, error) {
-	return currentData, nil
+func (s *Store) Read() (*Data, error) {
+	entries, err := s.b.All()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Data{Entries: entries}, nil
 }
 
-func findLatestID() int {
-	latest := 0
-	for _, e := range currentData.Entries {
-		if e.ID > latest {
-			latest = e.ID
+// UpdateEntry applies fn to the entry with the specified id
+// and stores it. Nothing is stored if fn returns an error.
+func (s *Store) UpdateEntry(id int, fn func(entry *DataEntry) error) (*DataEntry, error) {
+	return s.b.Update(id, func(entry *DataEntry) error {
+		if err := fn(entry); err != nil {
+			return err
 		}
-	}
-	return latest
+
+		if err := entry.validate(); err != nil {
+			return fmt.Errorf("entry validation failed: %w", err)
+		}
+
+		return nil
+	})
 }
 
-func (e *DataEntry) validate() error {
-	if e.MinioLink == "" {
-		return errors.New("minio link cannot be empty")
-	}
-	if e.YOURLSLink == "" {
-		return errors.New("yourls link cannot be empty")
-	}
-	return nil
+// FindByID returns the entry with the specified id.
+func (s *Store) FindByID(id int) (*DataEntry, error) {
+	return s.b.FindByID(id)
 }
 
-func removeExpiredEntries(expiry time.Duration) {
-	valid := make([]*DataEntry, 0, len(currentData.Entries))
-	for _, e := range currentData.Entries {
-		if expiry == -1 || time.Since(e.Timestamp) <= expiry {
-			valid = append(valid, e)
-		}
+// FindByToken returns the entry with the specified token.
+func (s *Store) FindByToken(token string) (*DataEntry, error) {
+	if token == "" {
+		return nil, errors.New("token cannot be empty")
 	}
-	currentData.Entries = valid
+
+	return s.b.FindByToken(token)
 }
 
-func prepareStorage() error {
-	exePath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to locate executable: %w", err)
+// FindByKeyword returns the entry with the specified YOURLS keyword.
+func (s *Store) FindByKeyword(keyword string) (*DataEntry, error) {
+	if keyword == "" {
+		return nil, errors.New("keyword cannot be empty")
 	}
 
-	storageDir = filepath.Join(filepath.Dir(exePath), storageDir)
-	storagePath = filepath.Join(storageDir, ".minyls.data.json")
+	return s.b.FindByKeyword(keyword)
+}
 
-	err = os.MkdirAll(storageDir, 0700)
-	if err != nil {
-		return fmt.Errorf("failed to create storage dir: %w", err)
+// FindByTag returns all entries with the specified tag.
+func (s *Store) FindByTag(tag string) ([]*DataEntry, error) {
+	return s.b.FindByTag(tag)
+}
+
+// FindExpiringBefore returns all entries expiring before t.
+func (s *Store) FindExpiringBefore(t time.Time) ([]*DataEntry, error) {
+	return s.b.FindExpiringBefore(t)
+}
+
+func (s *Store) Close() error {
+	return s.b.Close()
+}
+
+// ErrEntryNotFound is returned if a requested entry does not exist.
+var ErrEntryNotFound = errors.New("entry not found")
+
+// Exhausted reports whether the download limit of the entry has been reached.
+func (e *DataEntry) Exhausted() bool {
+	return e.MaxDownloads > 0 && e.Downloads >= e.MaxDownloads
+}
+
+// NeverExpires reports whether the entry has no expiry,
+// which is stored as a negative Expiry (e.g. -1).
+func (e *DataEntry) NeverExpires() bool {
+	return e.Expiry < 0
+}
+
+// ExpiresAt returns the time at which the entry expires
+// or the zero time if it never expires, see NeverExpires.
+func (e *DataEntry) ExpiresAt() time.Time {
+	if e.NeverExpires() {
+		return time.Time{}
 	}
 
-	storageFile, err = os.OpenFile(storagePath, os.O_RDWR|os.O_CREATE, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to open storage file: %w", err)
+	return e.Timestamp.Add(e.Expiry)
+}
+
+// Expired reports whether the entry has expired at now.
+func (e *DataEntry) Expired(now time.Time) bool {
+	return !e.NeverExpires() && !now.Before(e.ExpiresAt())
+}
+
+// Keyword returns the YOURLS keyword of the entry.
+func (e *DataEntry) Keyword() string {
+	u, err := url.Parse(e.YOURLSLink)
+	if err != nil || u.Path == "" || u.Path == "/" {
+		return ""
 	}
 
-	return nil
+	return path.Base(u.Path)
 }
 
-func loadData() error {
-	if _, err := storageFile.Seek(0, io.SeekStart); err != nil {
-		return fmt.Errorf("seek failed: %w", err)
+// Statuses of an entry, see Status.
+const (
+	StatusActive   = "active"
+	StatusExpired  = "expired"
+	StatusDeleted  = "deleted"
+	StatusOrphaned = "orphaned"
+)
+
+// Status returns the status of the entry at now as far as it is known
+// locally. Links which have been revoked or reached their download limit
+// are reported as expired. Entries are only reported as orphaned if they
+// do not reference an object, checking whether the object or short link
+// still exists is up to the caller.
+func (e *DataEntry) Status(now time.Time) string {
+	switch {
+	case e.Deleted:
+		return StatusDeleted
+	case e.Bucket == "" || e.Object == "":
+		return StatusOrphaned
+	case e.Revoked, e.Exhausted(), e.Expired(now):
+		return StatusExpired
+	default:
+		return StatusActive
 	}
+}
 
-	decoder := json.NewDecoder(storageFile)
-	d := &Data{}
+// Policy returns 'public' or 'private' depending on the
+// bucket of the entry or an empty string if it is unknown.
+func (e *DataEntry) Policy() string {
+	bucket := e.Bucket
+	if bucket == "" && e.Token == "" {
+		// older entries only contain the link
+		bucket, _, _ = objectFromLink(e.MinioLink)
+	}
 
-	err := decoder.Decode(d)
-	if errors.Is(err, io.EOF) {
-		d.Entries = []*DataEntry{}
-	} else if err != nil {
-		return fmt.Errorf("decode failed: %w", err)
+	switch {
+	case strings.HasSuffix(bucket, "-public"):
+		return "public"
+	case strings.HasSuffix(bucket, "-private"):
+		return "private"
+	default:
+		return ""
 	}
+}
 
-	currentData = d
-	return nil
+// HasTag reports whether the entry has the specified tag.
+func (e *DataEntry) HasTag(tag string) bool {
+	return slices.Contains(e.Tags, tag)
 }
 
-func writeData() error {
-	if err := storageFile.Truncate(0); err != nil {
-		return fmt.Errorf("truncate failed: %w", err)
+func (e *DataEntry) validate() error {
+	if e.MinioLink == "" {
+		return errors.New("minio link cannot be empty")
 	}
-	if _, err := storageFile.Seek(0, io.SeekStart); err != nil {
-		return fmt.Errorf("seek failed: %w", err)
+	if e.YOURLSLink == "" {
+		return errors.New("yourls link cannot be empty")
 	}
+	return nil
+}
 
-	encoder := json.NewEncoder(storageFile)
-	if err := encoder.Encode(currentData); err != nil {
-		return fmt.Errorf("encode failed: %w", err)
+func prepareStorageDir() (string, error) {
+	dir, err := paths.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate storage dir: %w", err)
 	}
 
-	if err := storageFile.Sync(); err != nil {
-		return fmt.Errorf("sync failed: %w", err)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", fmt.Errorf("failed to create storage dir: %w", err)
 	}
 
-	return nil
+	return dir, nil
 }
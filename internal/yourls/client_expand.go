@@ -0,0 +1,57 @@
+package yourls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrNotFound is returned if a short url does not exist.
+var ErrNotFound = errors.New("short url not found")
+
+// Expand returns the long url of the short url with the specified keyword.
+// It returns ErrNotFound if the short url does not exist (anymore).
+func (c *Client) Expand(ctx context.Context, keyword string) (string, error) {
+	v := make(map[string]string)
+	v["signature"] = c.signature
+	v["action"] = "expand"
+	v["format"] = "json"
+	v["shorturl"] = keyword
+
+	resp, err := c.doAPIRequest(ctx, v)
+	if err != nil {
+		return "", fmt.Errorf("failed to do api request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(
+			"unexpected status code: %d (status: %s)",
+			resp.StatusCode,
+			resp.Status,
+		)
+	}
+
+	res := &expandResponse{}
+	err = json.NewDecoder(resp.Body).Decode(res)
+	if err != nil {
+		return "", fmt.Errorf("could not decode json response: %w", err)
+	}
+
+	if res.LongURL == "" {
+		return "", ErrNotFound
+	}
+
+	return res.LongURL, nil
+}
+
+type expandResponse struct {
+	LongURL string `json:"longurl"`
+	Message string `json:"message"`
+}
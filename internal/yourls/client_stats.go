@@ -0,0 +1,50 @@
+package yourls
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Stats contains the statistics of the YOURLS instance.
+type Stats struct {
+	TotalLinks  string `json:"total_links"`
+	TotalClicks string `json:"total_clicks"`
+}
+
+// Stats returns the statistics of the YOURLS instance. Since it only
+// requires a valid signature it is useful to check the connection.
+func (c *Client) Stats(ctx context.Context) (*Stats, error) {
+	v := make(map[string]string)
+	v["signature"] = c.signature
+	v["action"] = "db-stats"
+	v["format"] = "json"
+
+	resp, err := c.doAPIRequest(ctx, v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do api request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"unexpected status code: %d (status: %s)",
+			resp.StatusCode,
+			resp.Status,
+		)
+	}
+
+	res := &statsResponse{}
+	err = json.NewDecoder(resp.Body).Decode(res)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode json response: %w", err)
+	}
+
+	return &res.DBStats, nil
+}
+
+type statsResponse struct {
+	DBStats Stats  `json:"db-stats"`
+	Message string `json:"message"`
+}
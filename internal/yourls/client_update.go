@@ -0,0 +1,60 @@
+package yourls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// Update points the short url with the specified keyword to input.
+//
+// YOURLS does not support updating short urls by default,
+// the 'update' action is provided by the API Edit URL plugin.
+func (c *Client) Update(ctx context.Context, keyword string, input string, title string) error {
+	u, err := url.Parse(input)
+	if err != nil {
+		return fmt.Errorf("invalid input provided: %w", err)
+	}
+
+	v := make(map[string]string)
+	v["signature"] = c.signature
+	v["action"] = "update"
+	v["format"] = "json"
+	v["shorturl"] = keyword
+	v["url"] = u.String()
+	v["title"] = title
+
+	resp, err := c.doAPIRequest(ctx, v)
+	if err != nil {
+		return fmt.Errorf("failed to do api request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf(
+			"unexpected status code: %d (status: %s)",
+			resp.StatusCode,
+			resp.Status,
+		)
+	}
+
+	return nil
+}
+
+// Keyword returns the keyword of a short url created by Shorten.
+func Keyword(shortURL string) (string, error) {
+	u, err := url.Parse(shortURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid short url provided: %w", err)
+	}
+
+	keyword := path.Base(u.Path)
+	if keyword == "/" || keyword == "." {
+		return "", errors.New("short url has no keyword")
+	}
+
+	return keyword, nil
+}
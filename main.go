@@ -32,6 +32,13 @@ func init() {
 const minArgs = 2
 
 func main() {
+	var err error
+	os.Args, err = cli.ParseGlobalFlags(os.Args)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+
 	if len(os.Args) < minArgs {
 		fmt.Println("error: no command provided")
 		fmt.Println()
@@ -54,16 +61,26 @@ func printHelp() {
 	fmt.Println(appGithubLink)
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("	minyls <command> <parameters>")
+	fmt.Println("	minyls [--profile name] [--log-level level] [--verbose | -v] [--output json|yaml|table|plain] [--no-clipboard] [--format link|markdown|image|html|template] <command> <parameters>")
 	fmt.Println()
 	fmt.Println("Available commands and parameters:")
 	fmt.Println("	help")
 	fmt.Println("	version")
-	fmt.Println("	upload		[filepath] [policy]")
-	fmt.Println("	list")
+	fmt.Println("	upload		[filepath] [policy] [--password] [--max-downloads n | --once] [--tag tag] [--title title] [--note note] [--qr] [--qr-out file]")
+	fmt.Println("	list		[--since t] [--until t] [--policy policy] [--name glob] [--expiring-within d] [--tag tag] [--sort size|date|expiry] [--limit n] [--columns c1,c2] [--check]")
+	fmt.Println("	search		[query] [list flags]")
 	fmt.Println("	download	[id] [filepath]")
 	fmt.Println("	delete		[id]")
 	fmt.Println("	clear		[option]")
+	fmt.Println("	serve")
+	fmt.Println("	revoke		[id]")
+	fmt.Println("	sharex		[filepath]")
+	fmt.Println("	reshare		[id] [--expiry duration] [--new-link]")
+	fmt.Println("	policy		[id] [policy] [--new-link]")
+	fmt.Println("	history		[repair]")
+	fmt.Println("	config		[init | show | validate]")
+	fmt.Println("	doctor")
+	fmt.Println("	qr		[id] [--out file.png|file.svg] [--size px]")
 }
 
 // logging may be used here for cli commands
@@ -89,12 +106,72 @@ func handleCommandLine() {
 			log.Log().Err(err).Str("func", "handleCommandLine").Msg("list failed")
 			os.Exit(1)
 		}
+	case "search":
+		err := cli.Search()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("search failed")
+			os.Exit(1)
+		}
 	case "download":
 		fmt.Println("download command, not implemented")
 	case "delete":
 		fmt.Println("delete command, not implemented")
 	case "clear":
 		fmt.Println("clear command, not implemented")
+	case "serve":
+		err := cli.Serve()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("serve failed")
+			os.Exit(1)
+		}
+	case "revoke":
+		err := cli.Revoke()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("revoke failed")
+			os.Exit(1)
+		}
+	case "sharex":
+		err := cli.ShareX(appName)
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("sharex failed")
+			os.Exit(1)
+		}
+	case "reshare":
+		err := cli.Reshare()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("reshare failed")
+			os.Exit(1)
+		}
+	case "policy":
+		err := cli.Policy()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("policy failed")
+			os.Exit(1)
+		}
+	case "history":
+		err := cli.History()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("history failed")
+			os.Exit(1)
+		}
+	case "config":
+		err := cli.Config()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("config failed")
+			os.Exit(1)
+		}
+	case "doctor":
+		err := cli.Doctor()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("doctor failed")
+			os.Exit(1)
+		}
+	case "qr":
+		err := cli.QR()
+		if err != nil {
+			log.Log().Err(err).Str("func", "handleCommandLine").Msg("qr failed")
+			os.Exit(1)
+		}
 	default:
 		fmt.Println("error: unrecognized command:", command)
 		fmt.Println()